@@ -23,9 +23,12 @@ type ChiLambda struct {
 
 // New creates a new instance of the ChiLambda object.
 // Receives an initialized *chi.Mux object - normally created with chi.NewRouter().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the ChiLambda object.
-func New(chi *chi.Mux) *ChiLambda {
-	return &ChiLambda{chiMux: chi}
+func New(chi *chi.Mux, opts ...core.Option) *ChiLambda {
+	g := &ChiLambda{chiMux: chi}
+	g.ApplyOptions(opts...)
+	return g
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -33,7 +36,7 @@ func New(chi *chi.Mux) *ChiLambda {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *ChiLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	chiRequest, err := g.ProxyEventToHTTPRequest(req)
-	return g.proxyInternal(chiRequest, err)
+	return g.proxyInternal(context.Background(), req, chiRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -41,21 +44,25 @@ func (g *ChiLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayP
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *ChiLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	chiRequest, err := g.EventToRequestWithContext(ctx, req)
-	return g.proxyInternal(chiRequest, err)
+	return g.proxyInternal(ctx, req, chiRequest, err)
 }
 
-func (g *ChiLambda) proxyInternal(chiRequest *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+func (g *ChiLambda) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, chiRequest *http.Request, err error) (events.APIGatewayProxyResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return g.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriter()
-	g.chiMux.ServeHTTP(http.ResponseWriter(respWriter), chiRequest)
+	respWriter := g.NewProxyResponseWriter()
+	defer respWriter.Release()
+	chiRequest = respWriter.BindRequest(chiRequest, g.TimeoutReserve())
+	if err := g.Serve(g.chiMux, respWriter, chiRequest); err != nil {
+		return g.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return g.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
@@ -20,9 +20,12 @@ type ChiLambdaV2 struct {
 
 // New creates a new instance of the ChiLambdaV2 object.
 // Receives an initialized *chi.Mux object - normally created with chi.NewRouter().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the ChiLambdaV2 object.
-func NewV2(chi *chi.Mux) *ChiLambdaV2 {
-	return &ChiLambdaV2{chiMux: chi}
+func NewV2(chi *chi.Mux, opts ...core.Option) *ChiLambdaV2 {
+	g := &ChiLambdaV2{chiMux: chi}
+	g.ApplyOptions(opts...)
+	return g
 }
 
 // Proxy receives an API Gateway proxy V2 event, transforms it into an http.Request
@@ -30,7 +33,7 @@ func NewV2(chi *chi.Mux) *ChiLambdaV2 {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *ChiLambdaV2) Proxy(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	chiRequest, err := g.ProxyEventToHTTPRequest(req)
-	return g.proxyInternal(chiRequest, err)
+	return g.proxyInternal(context.Background(), req, chiRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy V2 event,
@@ -38,21 +41,25 @@ func (g *ChiLambdaV2) Proxy(req events.APIGatewayV2HTTPRequest) (events.APIGatew
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *ChiLambdaV2) ProxyWithContextV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	chiRequest, err := g.EventToRequestWithContext(ctx, req)
-	return g.proxyInternal(chiRequest, err)
+	return g.proxyInternal(ctx, req, chiRequest, err)
 }
 
-func (g *ChiLambdaV2) proxyInternal(chiRequest *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
+func (g *ChiLambdaV2) proxyInternal(ctx context.Context, event events.APIGatewayV2HTTPRequest, chiRequest *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return g.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriterV2()
-	g.chiMux.ServeHTTP(http.ResponseWriter(respWriter), chiRequest)
+	respWriter := g.NewProxyResponseWriterV2()
+	defer respWriter.Release()
+	chiRequest = respWriter.BindRequest(chiRequest, g.TimeoutReserve())
+	if err := g.Serve(g.chiMux, respWriter, chiRequest); err != nil {
+		return g.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return g.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
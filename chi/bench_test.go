@@ -0,0 +1,28 @@
+package chiadapter_test
+
+import (
+	"net/http"
+	"testing"
+
+	chiadapter "github.com/awslabs/aws-lambda-go-api-proxy/chi"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+	"github.com/go-chi/chi/v5"
+)
+
+func newBenchmarkRouter() *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/orders/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(proxybench.Body)
+	})
+	return r
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway:   chiadapter.New(newBenchmarkRouter()).ProxyWithContext,
+		APIGatewayV2: chiadapter.NewV2(newBenchmarkRouter()).ProxyWithContextV2,
+	})
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type basePathKey struct{}
+
+// GetBasePathFromContext retrieves the path prefix that was removed from the
+// request path by the automatic base path handling enabled with
+// WithAutoBasePath. The prefix is the part of the public URL the client
+// used that precedes the routed path, for example /prod or /api.
+func GetBasePathFromContext(ctx context.Context) (string, bool) {
+	basePath, ok := ctx.Value(basePathKey{}).(string)
+	return basePath, ok
+}
+
+// withBasePath stores the public path prefix of the request in its context so
+// that the response writer can re-add it to the paths in the response.
+func withBasePath(req *http.Request, basePath string) *http.Request {
+	if basePath == "" {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), basePathKey{}, basePath))
+}
+
+// copyBasePath copies the public path prefix stored in the context of from to
+// the context of to.
+func copyBasePath(from, to *http.Request) *http.Request {
+	if basePath, ok := GetBasePathFromContext(from.Context()); ok {
+		return withBasePath(to, basePath)
+	}
+	return to
+}
+
+// basePathV1 returns the prefix to remove from the path of an API Gateway REST
+// API event and the public prefix of the request. Execute-api URLs include the
+// stage, which API Gateway removes from the event path but not from the
+// path in the request context. Custom domain base path mappings remain part
+// of the event path and are found by comparing it with the resource.
+func (o *options) basePathV1(req events.APIGatewayProxyRequest) (strip, prefix string) {
+	strip = o.stripBasePath
+	if strip == "" {
+		strip = routePrefix(req.Path, expandRoute(req.Resource, req.PathParameters))
+	}
+
+	stage := ""
+	if contextPath := req.RequestContext.Path; contextPath != "" && strings.HasSuffix(contextPath, req.Path) {
+		stage = strings.TrimSuffix(contextPath[:len(contextPath)-len(req.Path)], "/")
+	} else if isExecuteAPIDomain(req.RequestContext.DomainName) && req.RequestContext.Stage != "" {
+		stage = "/" + req.RequestContext.Stage
+	}
+	return strip, stage + strip
+}
+
+// basePathV2 returns the prefix to remove from the path of an API Gateway HTTP
+// API event, which is also the public prefix of the request. Named stages are
+// part of the raw path on execute-api URLs. Custom domain API mappings are
+// found by comparing the path with the route key.
+func (o *options) basePathV2(req events.APIGatewayV2HTTPRequest, path string) (strip, prefix string) {
+	strip = o.stripBasePath
+	if strip == "" {
+		stage := req.RequestContext.Stage
+		if stage != "" && stage != "$default" && isExecuteAPIDomain(req.RequestContext.DomainName) &&
+			(path == "/"+stage || strings.HasPrefix(path, "/"+stage+"/")) {
+			strip = "/" + stage
+		} else if route := strings.Fields(req.RouteKey); len(route) == 2 {
+			strip = routePrefix(path, expandRoute(route[1], req.PathParameters))
+		}
+	}
+	return strip, strip
+}
+
+// basePathALB returns the prefix to remove from the path of an ALB event.
+// Load balancers have no stages or mappings, so only the configured base path
+// is used.
+func (o *options) basePathALB() (strip, prefix string) {
+	return o.stripBasePath, o.stripBasePath
+}
+
+func isExecuteAPIDomain(domainName string) bool {
+	return strings.Contains(domainName, ".execute-api.")
+}
+
+// expandRoute replaces the path parameters of an API Gateway resource or route
+// such as /pets/{id} or /{proxy+} with their values. Returns an empty string
+// if a parameter value is missing.
+func expandRoute(route string, params map[string]string) string {
+	if route == "" {
+		return ""
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(route, '{')
+		if start < 0 {
+			b.WriteString(route)
+			return b.String()
+		}
+		end := strings.IndexByte(route[start:], '}')
+		if end < 0 {
+			return ""
+		}
+		value, ok := params[strings.TrimSuffix(route[start+1:start+end], "+")]
+		if !ok {
+			return ""
+		}
+		b.WriteString(route[:start])
+		b.WriteString(value)
+		route = route[start+end+1:]
+	}
+}
+
+// routePrefix returns the part of path that precedes route.
+func routePrefix(path, route string) string {
+	if route == "" {
+		return ""
+	}
+	if route == "/" {
+		return strings.TrimSuffix(path, "/")
+	}
+	if len(path) > len(route) && strings.HasSuffix(path, route) {
+		return path[:len(path)-len(route)]
+	}
+	return ""
+}
+
+// trimBasePath removes basePath from path and makes sure the result starts
+// with a slash.
+func trimBasePath(path, basePath string) string {
+	if basePath != "" && len(basePath) > 1 {
+		if strings.HasPrefix(path, basePath) {
+			path = strings.Replace(path, basePath, "", 1)
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// rewriteBasePath adds basePath to the absolute paths in the Location and
+// Content-Location headers and to the Path attribute of the cookies set by
+// the response, so that they point to the public URL of the API.
+func rewriteBasePath(header http.Header, basePath string) {
+	if basePath == "" {
+		return
+	}
+	for _, key := range []string{"Location", "Content-Location"} {
+		values := header[key]
+		for i, value := range values {
+			if strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "//") {
+				values[i] = basePath + value
+			}
+		}
+	}
+	cookies := header["Set-Cookie"]
+	for i, cookie := range cookies {
+		cookies[i] = rewriteCookiePath(cookie, basePath)
+	}
+}
+
+func rewriteCookiePath(cookie, basePath string) string {
+	attributes := strings.Split(cookie, ";")
+	for i, attribute := range attributes[1:] {
+		name, value, _ := strings.Cut(attribute, "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "path") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, "/") {
+			continue
+		}
+		if value == "/" {
+			value = basePath
+		} else {
+			value = basePath + value
+		}
+		attributes[i+1] = " Path=" + value
+	}
+	return strings.Join(attributes, ";")
+}
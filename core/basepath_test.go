@@ -0,0 +1,197 @@
+package core_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Automatic base path tests", func() {
+	autoBasePath := core.WithAutoBasePath()
+
+	Context("REST API events", func() {
+		It("Detects the stage of execute-api URLs", func() {
+			req := getProxyRequest("/pets/1", "GET")
+			req.Resource = "/pets/{id}"
+			req.PathParameters = map[string]string{"id": "1"}
+			req.RequestContext = getRequestContext()
+			req.RequestContext.Path = "/prod/pets/1"
+
+			httpReq, err := core.NewRequestAccessor(autoBasePath).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/pets/1"))
+			basePath, ok := core.GetBasePathFromContext(httpReq.Context())
+			Expect(ok).To(BeTrue())
+			Expect(basePath).To(Equal("/prod"))
+		})
+
+		It("Falls back to the stage name on execute-api domains", func() {
+			req := getProxyRequest("/pets", "GET")
+			req.RequestContext = getRequestContext()
+
+			httpReq, err := core.NewRequestAccessor(autoBasePath).EventToRequest(req)
+			Expect(err).To(BeNil())
+			basePath, _ := core.GetBasePathFromContext(httpReq.Context())
+			Expect(basePath).To(Equal("/prod"))
+		})
+
+		It("Detects custom domain base path mappings", func() {
+			req := getProxyRequest("/api/pets/1", "GET")
+			req.Resource = "/{proxy+}"
+			req.PathParameters = map[string]string{"proxy": "pets/1"}
+			req.RequestContext = getRequestContext()
+			req.RequestContext.DomainName = "api.example.com"
+			req.RequestContext.Path = "/api/pets/1"
+
+			httpReq, err := core.NewRequestAccessor(autoBasePath).EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/pets/1"))
+			basePath, _ := core.GetBasePathFromContext(httpReq.Context())
+			Expect(basePath).To(Equal("/api"))
+		})
+
+		It("Detects the mapping of root resources", func() {
+			req := getProxyRequest("/api", "GET")
+			req.Resource = "/"
+			req.RequestContext.DomainName = "api.example.com"
+			req.RequestContext.Path = "/api"
+
+			httpReq, err := core.NewRequestAccessor(autoBasePath).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/"))
+			basePath, _ := core.GetBasePathFromContext(httpReq.Context())
+			Expect(basePath).To(Equal("/api"))
+		})
+
+		It("Leaves the path alone when disabled", func() {
+			req := getProxyRequest("/api/pets", "GET")
+			req.Resource = "/pets"
+			httpReq, err := core.NewRequestAccessor().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/api/pets"))
+			_, ok := core.GetBasePathFromContext(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("HTTP API events", func() {
+		It("Strips named stages on execute-api URLs", func() {
+			req := getProxyRequestV2("/dev/pets", "GET")
+			req.RouteKey = "GET /pets"
+			req.RequestContext.Stage = "dev"
+			req.RequestContext.DomainName = "12abcdefgh.execute-api.us-east-2.amazonaws.com"
+
+			httpReq, err := core.NewRequestAccessorV2(autoBasePath).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/pets"))
+			basePath, _ := core.GetBasePathFromContext(httpReq.Context())
+			Expect(basePath).To(Equal("/dev"))
+		})
+
+		It("Keeps the path of the default stage", func() {
+			req := getProxyRequestV2("/pets", "GET")
+			req.RouteKey = "$default"
+			req.RequestContext.Stage = "$default"
+			req.RequestContext.DomainName = "12abcdefgh.execute-api.us-east-2.amazonaws.com"
+
+			httpReq, err := core.NewRequestAccessorV2(autoBasePath).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/pets"))
+			_, ok := core.GetBasePathFromContext(httpReq.Context())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Detects custom domain API mappings from the route key", func() {
+			req := getProxyRequestV2("/v1/pets/7", "GET")
+			req.RouteKey = "GET /pets/{id}"
+			req.PathParameters = map[string]string{"id": "7"}
+			req.RequestContext.Stage = "$default"
+			req.RequestContext.DomainName = "api.example.com"
+
+			httpReq, err := core.NewRequestAccessorV2(autoBasePath).EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/pets/7"))
+			basePath, _ := core.GetBasePathFromContext(httpReq.Context())
+			Expect(basePath).To(Equal("/v1"))
+		})
+
+		It("Prefers the configured base path", func() {
+			req := getProxyRequestV2("/v1/pets", "GET")
+			req.RouteKey = "ANY /{proxy+}"
+			req.PathParameters = map[string]string{"proxy": "v1/pets"}
+
+			httpReq, err := core.NewRequestAccessorV2(autoBasePath, core.WithBasePath("v1")).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/pets"))
+			basePath, _ := core.GetBasePathFromContext(httpReq.Context())
+			Expect(basePath).To(Equal("/v1"))
+		})
+	})
+
+	Context("Response rewriting", func() {
+		bind := func(basePath string) *http.Request {
+			req := getProxyRequest("/prod/pets", "GET")
+			req.Resource = "/pets"
+			req.RequestContext.Path = "/" + basePath + "/pets"
+			httpReq, err := core.NewRequestAccessor(autoBasePath).EventToRequest(req)
+			Expect(err).To(BeNil())
+			return httpReq
+		}
+
+		It("Prefixes redirects and cookie paths", func() {
+			w := core.NewProxyResponseWriter()
+			w.BindRequest(bind("prod"), time.Second)
+			w.Header().Set("Location", "/login?next=%2Fpets")
+			w.Header().Set("Content-Location", "https://example.com/pets")
+			w.Header().Add("Set-Cookie", "session=abc; Path=/; HttpOnly")
+			w.Header().Add("Set-Cookie", "pref=1; path=/pets; Secure")
+			w.Header().Add("Set-Cookie", "plain=1")
+			w.WriteHeader(http.StatusFound)
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders["Location"]).To(Equal([]string{"/prod/login?next=%2Fpets"}))
+			Expect(resp.MultiValueHeaders["Content-Location"]).To(Equal([]string{"https://example.com/pets"}))
+			Expect(resp.MultiValueHeaders["Set-Cookie"]).To(Equal([]string{
+				"session=abc; Path=/prod; HttpOnly",
+				"pref=1; Path=/prod/pets; Secure",
+				"plain=1",
+			}))
+		})
+
+		It("Leaves protocol-relative locations alone", func() {
+			w := core.NewProxyResponseWriterALB()
+			w.BindRequest(bind("prod"), time.Second)
+			w.Header().Set("Location", "//example.com/login")
+			w.WriteHeader(http.StatusFound)
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders["Location"]).To(Equal([]string{"//example.com/login"}))
+		})
+
+		It("Rewrites the cookies of HTTP API responses", func() {
+			req := getProxyRequestV2("/dev/pets", "GET")
+			req.RequestContext.Stage = "dev"
+			req.RequestContext.DomainName = "12abcdefgh.execute-api.us-east-2.amazonaws.com"
+			httpReq, err := core.NewRequestAccessorV2(autoBasePath).EventToRequest(req)
+			Expect(err).To(BeNil())
+
+			w := core.NewProxyResponseWriterV2()
+			w.BindRequest(httpReq, time.Second)
+			w.Header().Set("Location", "/login")
+			w.Header().Add("Set-Cookie", "session=abc; Path=/")
+			w.WriteHeader(http.StatusSeeOther)
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.Headers["Location"]).To(Equal("/dev/login"))
+			Expect(resp.Cookies).To(Equal([]string{"session=abc; Path=/dev"}))
+		})
+	})
+})
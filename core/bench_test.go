@@ -0,0 +1,25 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+func BenchmarkBinaryResponse(b *testing.B) {
+	body := make([]byte, 64<<10)
+	for i := range body {
+		body[i] = byte(i)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		w := core.NewProxyResponseWriterV2()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(body)
+		if _, err := w.GetProxyResponse(); err != nil {
+			b.Fatal(err)
+		}
+		w.Release()
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// BinaryMode selects when the response writers base64 encode response bodies.
+type BinaryMode int
+
+const (
+	// BinaryAuto encodes bodies whose Content-Type matches the binary media
+	// types of the policy, and bodies that are not valid UTF-8 and would be
+	// corrupted in the JSON response.
+	BinaryAuto BinaryMode = iota
+	// BinaryAlways encodes all bodies. REST APIs configured with the */*
+	// binary media type expect this mode.
+	BinaryAlways
+	// BinaryNever never encodes bodies. Bodies that are not valid UTF-8 are
+	// corrupted in this mode.
+	BinaryNever
+)
+
+// BinaryPolicy decides which response bodies are returned base64 encoded.
+// The zero value encodes only bodies that are not valid UTF-8.
+type BinaryPolicy struct {
+	// Mode selects when bodies are encoded. MediaTypes and ContentEncoding
+	// only apply in BinaryAuto mode.
+	Mode BinaryMode
+
+	// MediaTypes are the media types of responses that are always encoded,
+	// such as the binaryMediaTypes of a REST API. Patterns can be exact media
+	// types such as application/pdf, wildcard subtypes such as image/* or */*.
+	MediaTypes []string
+
+	// ContentEncoding encodes the bodies of responses with a Content-Encoding
+	// header, such as compressed text that happens to be valid UTF-8.
+	ContentEncoding bool
+}
+
+// isBinary reports whether a response body with the given headers must be
+// base64 encoded.
+func (p BinaryPolicy) isBinary(header http.Header, body []byte) bool {
+	switch p.Mode {
+	case BinaryAlways:
+		return true
+	case BinaryNever:
+		return false
+	}
+	if p.ContentEncoding && len(contentEncodings(header)) > 0 {
+		return true
+	}
+	return matchesMediaType(header.Get(contentTypeHeaderKey), p.MediaTypes) || !utf8.Valid(body)
+}
+
+// responseOptions holds the accessor options that the response writers
+// honour. It is embedded in every writer.
+type responseOptions struct {
+	binaryPolicy                BinaryPolicy
+	disableContentTypeDetection bool
+	defaultContentType          string
+	compression                 *Compression
+	responseSizeLimit           *ResponseSizeLimit
+	logger                      *slog.Logger
+}
+
+// setDefaultContentType sets the Content-Type of a response whose handler
+// did not set one, either to the configured default type or to the type
+// detected from the first bytes of the body with http.DetectContentType.
+// If the content type cannot be detected it is set to
+// "application/octet-stream" by the DetectContentType method.
+func (o responseOptions) setDefaultContentType(header http.Header, body []byte) {
+	if header.Get(contentTypeHeaderKey) != "" {
+		return
+	}
+	switch {
+	case o.defaultContentType != "":
+		header.Add(contentTypeHeaderKey, o.defaultContentType)
+	case !o.disableContentTypeDetection:
+		header.Add(contentTypeHeaderKey, http.DetectContentType(body))
+	}
+}
+
+// encodeBody returns the body of a response as a string for the proxy
+// response object and whether it is base64 encoded. Compressed bodies are
+// always encoded.
+func (o responseOptions) encodeBody(header http.Header, body []byte, compressed bool) (string, bool) {
+	if compressed || o.binaryPolicy.isBinary(header, body) {
+		return encodeBase64(body), true
+	}
+	return string(body), false
+}
+
+// encodeBase64 returns the standard base64 encoding of body. The encoding is
+// written into a buffer that then backs the returned string, saving the copy
+// made by EncodeToString. The buffer is not referenced anywhere else.
+func encodeBase64(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	buf := make([]byte, base64.StdEncoding.EncodedLen(len(body)))
+	base64.StdEncoding.Encode(buf, body)
+	return unsafe.String(unsafe.SliceData(buf), len(buf))
+}
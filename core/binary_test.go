@@ -0,0 +1,122 @@
+package core_test
+
+import (
+	"net/http"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Binary policy tests", func() {
+	Context("Base64 encoding", func() {
+		type response struct {
+			contentType     string
+			contentEncoding string
+			body            string
+		}
+		text := response{contentType: "text/plain", body: "hello"}
+		gzipped := response{contentType: "text/plain", contentEncoding: "gzip", body: "hello"}
+		invalidUTF8 := response{contentType: "text/plain", body: "\xff\xfe"}
+		image := response{contentType: "image/png", body: "PNG"}
+
+		for _, c := range []struct {
+			name     string
+			policy   core.BinaryPolicy
+			response response
+			binary   bool
+		}{
+			{"auto text", core.BinaryPolicy{}, text, false},
+			{"auto invalid UTF-8", core.BinaryPolicy{}, invalidUTF8, true},
+			{"auto encoded text", core.BinaryPolicy{}, gzipped, false},
+			{"auto media type", core.BinaryPolicy{MediaTypes: []string{"image/*"}}, image, true},
+			{"auto other media type", core.BinaryPolicy{MediaTypes: []string{"image/*"}}, text, false},
+			{"content encoding", core.BinaryPolicy{ContentEncoding: true}, gzipped, true},
+			{"content encoding without header", core.BinaryPolicy{ContentEncoding: true}, text, false},
+			{"always", core.BinaryPolicy{Mode: core.BinaryAlways}, text, true},
+			{"never", core.BinaryPolicy{Mode: core.BinaryNever, MediaTypes: []string{"*/*"}}, invalidUTF8, false},
+		} {
+			c := c
+			It("Applies the policy to "+c.name+" responses", func() {
+				opt := core.WithBinaryPolicy(c.policy)
+				write := func(w http.ResponseWriter) {
+					w.Header().Set("Content-Type", c.response.contentType)
+					if c.response.contentEncoding != "" {
+						w.Header().Set("Content-Encoding", c.response.contentEncoding)
+					}
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(c.response.body))
+				}
+
+				w := core.NewRequestAccessor(opt).NewProxyResponseWriter()
+				write(w)
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect(resp.IsBase64Encoded).To(Equal(c.binary))
+
+				w2 := core.NewRequestAccessorV2(opt).NewProxyResponseWriterV2()
+				write(w2)
+				resp2, err := w2.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect(resp2.IsBase64Encoded).To(Equal(c.binary))
+
+				wALB := core.NewRequestAccessorALB(opt).NewProxyResponseWriterALB()
+				write(wALB)
+				respALB, err := wALB.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect(respALB.IsBase64Encoded).To(Equal(c.binary))
+			})
+		}
+
+		It("Keeps the media types of the policy when content types are set", func() {
+			accessor := core.NewRequestAccessor(
+				core.WithBinaryPolicy(core.BinaryPolicy{ContentEncoding: true}),
+				core.WithBinaryContentTypes("application/protobuf"),
+			)
+
+			w := accessor.NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "application/protobuf")
+			w.Write([]byte("message"))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.IsBase64Encoded).To(BeTrue())
+			Expect(resp.Body).To(Equal("bWVzc2FnZQ=="))
+		})
+	})
+
+	Context("Content-Type detection", func() {
+		It("Detects the content type by default", func() {
+			w := core.NewRequestAccessor().NewProxyResponseWriter()
+			w.Write([]byte("<html><body>hello</body></html>"))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"text/html; charset=utf-8"}))
+		})
+
+		It("Can be disabled", func() {
+			w := core.NewRequestAccessorV2(core.WithContentTypeDetection(false)).NewProxyResponseWriterV2()
+			w.Write([]byte("<html><body>hello</body></html>"))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.Headers).ToNot(HaveKey("Content-Type"))
+		})
+
+		It("Uses the default content type", func() {
+			w := core.NewRequestAccessorALB(core.WithDefaultContentType("application/json")).NewProxyResponseWriterALB()
+			w.Write([]byte("<html><body>hello</body></html>"))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/json"}))
+		})
+
+		It("Keeps the content type set by the handler", func() {
+			w := core.NewRequestAccessor(core.WithDefaultContentType("application/json")).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("a,b"))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"text/csv"}))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrRequestBodyTooLarge is returned when the decoded body of an event is
+// larger than the maximum body size configured with WithMaxBodySize.
+var ErrRequestBodyTooLarge = errors.New("request body too large")
+
+// RequestBodyTooLargeError is returned by the accessors when the decoded body
+// of an event exceeds the maximum body size. It matches ErrRequestBodyTooLarge
+// with errors.Is.
+type RequestBodyTooLargeError struct {
+	// Size is the decoded size of the body, in bytes.
+	Size int64
+	// Limit is the maximum body size that applied to the request, in bytes.
+	Limit int64
+}
+
+func (e *RequestBodyTooLargeError) Error() string {
+	return fmt.Sprintf("%v: %d bytes exceeds the limit of %d bytes", ErrRequestBodyTooLarge, e.Size, e.Limit)
+}
+
+func (e *RequestBodyTooLargeError) Is(target error) bool {
+	return target == ErrRequestBodyTooLarge
+}
+
+// eventBody gives streaming access to the body of a proxy event. Base64
+// encoded bodies are decoded lazily while the handler reads them instead of
+// being decoded into memory up front.
+type eventBody struct {
+	raw           string
+	base64Encoded bool
+	contentLength int64
+}
+
+// newEventBody returns an eventBody for the raw body of an event. For base64
+// encoded bodies the decoded length is computed from the encoded length.
+// Returns an error if the length of a base64 encoded body is invalid.
+func newEventBody(raw string, base64Encoded bool) (*eventBody, error) {
+	if !base64Encoded {
+		return &eventBody{raw: raw, contentLength: int64(len(raw))}, nil
+	}
+
+	contentLength, err := decodedBase64Len(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &eventBody{raw: raw, base64Encoded: true, contentLength: contentLength}, nil
+}
+
+// reader returns a new reader over the decoded body.
+func (b *eventBody) reader() io.Reader {
+	if b.base64Encoded {
+		return base64.NewDecoder(base64.StdEncoding, strings.NewReader(b.raw))
+	}
+	return strings.NewReader(b.raw)
+}
+
+// setRequestBody sets the Body, ContentLength and GetBody fields of req so that
+// middleware can replay the body.
+func (b *eventBody) setRequestBody(req *http.Request) {
+	req.ContentLength = b.contentLength
+	if b.contentLength == 0 {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+		return
+	}
+
+	req.Body = io.NopCloser(b.reader())
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(b.reader()), nil
+	}
+}
+
+// decodedBase64Len returns the number of bytes encoded in the padded,
+// standard base64 string s without decoding it. Like
+// base64.StdEncoding.DecodeString, it ignores new line characters and
+// rejects characters outside of the alphabet and misplaced padding, so that
+// badly encoded bodies are refused before the handler reads them.
+func decodedBase64Len(s string) (int64, error) {
+	n, padding := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\r' || c == '\n':
+			continue
+		case c == '=':
+			padding++
+		case padding > 0 || !isBase64Char(c):
+			return 0, base64.CorruptInputError(i)
+		}
+		n++
+	}
+	if n%4 != 0 || padding > 2 {
+		return 0, base64.CorruptInputError(len(s))
+	}
+	return int64(n/4*3 - padding), nil
+}
+
+// isBase64Char reports whether c belongs to the standard base64 alphabet.
+func isBase64Char(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '+' || c == '/'
+}
@@ -0,0 +1,148 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event body tests", func() {
+	It("Rejects padded base64 bodies with invalid characters", func() {
+		for _, body := range []string{"!!!!", "aGk!", "aG=k", "a===", "aGVsbG8=\x00AAA"} {
+			req := getProxyRequest("/hello", "POST")
+			req.Body = body
+			req.IsBase64Encoded = true
+
+			_, err := core.NewRequestAccessor().EventToRequest(req)
+			Expect(err).ToNot(BeNil(), body)
+			Expect(core.IsInvalidEvent(err)).To(BeTrue(), body)
+		}
+	})
+
+	It("Ignores new lines in base64 bodies", func() {
+		req := getProxyRequest("/hello", "POST")
+		req.Body = "aGVs\r\nbG8="
+		req.IsBase64Encoded = true
+
+		httpReq, err := core.NewRequestAccessor().EventToRequest(req)
+		Expect(err).To(BeNil())
+		Expect(httpReq.ContentLength).To(Equal(int64(5)))
+		body, err := io.ReadAll(httpReq.Body)
+		Expect(err).To(BeNil())
+		Expect(string(body)).To(Equal("hello"))
+	})
+})
+
+const benchmarkBodySize = 4 << 20
+
+func benchmarkBase64Body(b *testing.B) string {
+	body := make([]byte, benchmarkBodySize)
+	if _, err := rand.Read(body); err != nil {
+		b.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(body)
+}
+
+// BenchmarkDecodeBodyUpFront measures the previous behaviour of decoding the
+// whole base64 body into memory before creating the request.
+func BenchmarkDecodeBodyUpFront(b *testing.B) {
+	encoded := benchmarkBase64Body(b)
+	b.SetBytes(benchmarkBodySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			b.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "https://example.com/upload", bytes.NewReader(decoded))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, req.Body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEventToRequestBase64Body(b *testing.B) {
+	accessor := core.RequestAccessor{}
+	event := events.APIGatewayProxyRequest{
+		Path:            "/upload",
+		HTTPMethod:      http.MethodPost,
+		Body:            benchmarkBase64Body(b),
+		IsBase64Encoded: true,
+	}
+	b.SetBytes(benchmarkBodySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		req, err := accessor.EventToRequestWithContext(context.Background(), event)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, req.Body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEventToRequestBase64BodyV2(b *testing.B) {
+	accessor := core.RequestAccessorV2{}
+	event := events.APIGatewayV2HTTPRequest{
+		RawPath: "/upload",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodPost},
+		},
+		Body:            benchmarkBase64Body(b),
+		IsBase64Encoded: true,
+	}
+	b.SetBytes(benchmarkBodySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		req, err := accessor.EventToRequestWithContext(context.Background(), event)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, req.Body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEventToRequestBase64BodyALB(b *testing.B) {
+	accessor := core.RequestAccessorALB{}
+	event := events.ALBTargetGroupRequest{
+		Path:            "/upload",
+		HTTPMethod:      http.MethodPost,
+		Body:            benchmarkBase64Body(b),
+		IsBase64Encoded: true,
+	}
+	b.SetBytes(benchmarkBodySize)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		req, err := accessor.EventToRequestWithContext(context.Background(), event)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, req.Body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// gzipWriterPool and brotliWriterPool hold compressors for reuse, as their
+// state is expensive to allocate for every response.
+var (
+	gzipWriterPool   = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+	brotliWriterPool = sync.Pool{New: func() any { return brotli.NewWriter(nil) }}
+)
+
+// DefaultCompressionMinSize is the size, in bytes, below which response
+// bodies are not compressed when Compression.MinSize is not set.
+const DefaultCompressionMinSize = 1024
+
+// DefaultCompressibleContentTypes are the media types of responses that are
+// compressed when Compression.ContentTypes is not set.
+var DefaultCompressibleContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/problem+json",
+	"application/ld+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// Compression configures the compression of response bodies enabled with
+// WithCompression. Bodies are compressed with brotli or gzip, depending on
+// the Accept-Encoding header of the request, and returned base64 encoded.
+type Compression struct {
+	// MinSize is the size, in bytes, below which bodies are not compressed.
+	// Zero uses DefaultCompressionMinSize.
+	MinSize int
+
+	// ContentTypes are the media types of the responses that are compressed.
+	// Patterns can be exact media types such as application/json, wildcard
+	// subtypes such as text/* or */*. Nil uses DefaultCompressibleContentTypes.
+	ContentTypes []string
+}
+
+// compressBody compresses the body of a response when compression is enabled,
+// the response is eligible and the client accepts a supported coding. The
+// Content-Encoding and Vary headers are set accordingly, Content-Length is
+// removed and a strong ETag is made weak, as it no longer identifies the
+// bytes of the response. It returns the body to send and whether it was
+// compressed. Responses that already have a Content-Encoding, partial
+// responses whose Content-Range describes the uncompressed body, event streams
+// and responses marked with Cache-Control: no-transform are never compressed.
+func (o responseOptions) compressBody(header http.Header, status int, acceptEncoding []string, body []byte) ([]byte, bool) {
+	c := o.compression
+	if c == nil || !bodyAllowedForStatus(status) || header.Get("Content-Encoding") != "" {
+		return body, false
+	}
+	if status == http.StatusPartialContent || header.Get("Content-Range") != "" {
+		return body, false
+	}
+	if strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-transform") {
+		return body, false
+	}
+
+	contentType := header.Get(contentTypeHeaderKey)
+	contentTypes := c.ContentTypes
+	if contentTypes == nil {
+		contentTypes = DefaultCompressibleContentTypes
+	}
+	if !matchesMediaType(contentType, contentTypes) || matchesMediaType(contentType, []string{"text/event-stream"}) {
+		return body, false
+	}
+
+	minSize := c.MinSize
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+	if len(body) < minSize {
+		return body, false
+	}
+
+	addVary(header, "Accept-Encoding")
+	encoding := negotiateEncoding(acceptEncoding)
+	if encoding == "" {
+		return body, false
+	}
+
+	compressed, err := compress(encoding, body)
+	if err != nil || len(compressed) >= len(body) {
+		return body, false
+	}
+	header.Set("Content-Encoding", encoding)
+	header.Del("Content-Length")
+	if etag := header.Get("Etag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("Etag", "W/"+etag)
+	}
+	return compressed, true
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w interface {
+		Write([]byte) (int, error)
+		Close() error
+	}
+	switch encoding {
+	case "br":
+		bw := brotliWriterPool.Get().(*brotli.Writer)
+		defer func() {
+			bw.Reset(io.Discard)
+			brotliWriterPool.Put(bw)
+		}()
+		bw.Reset(&buf)
+		w = bw
+	default:
+		gw := gzipWriterPool.Get().(*gzip.Writer)
+		defer func() {
+			gw.Reset(io.Discard)
+			gzipWriterPool.Put(gw)
+		}()
+		gw.Reset(&buf)
+		w = gw
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// negotiateEncoding returns the supported coding preferred by the client
+// according to the Accept-Encoding header values, or an empty string if the
+// client accepts none. Brotli is chosen over gzip when both are equally
+// preferred.
+func negotiateEncoding(acceptEncoding []string) string {
+	best, bestQ := "", 0.0
+	wildcard := -1.0
+	accepted := map[string]float64{}
+	for _, value := range acceptEncoding {
+		for _, item := range strings.Split(value, ",") {
+			coding, q := parseQualityItem(item)
+			switch coding {
+			case "":
+				continue
+			case "*":
+				wildcard = q
+			default:
+				accepted[coding] = q
+			}
+		}
+	}
+	for _, coding := range []string{"br", "gzip"} {
+		q, ok := accepted[coding]
+		if !ok && coding == "gzip" {
+			q, ok = accepted["x-gzip"]
+		}
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// parseQualityItem parses an element of a header list with an optional
+// quality value, such as "gzip;q=0.8". Elements without a valid quality
+// value have a quality of 1.
+func parseQualityItem(item string) (string, float64) {
+	params := strings.Split(item, ";")
+	value := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, param := range params[1:] {
+		name, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			q = parsed
+		}
+	}
+	return value, q
+}
+
+// addVary adds field to the Vary header unless it is already listed.
+func addVary(header http.Header, field string) {
+	for _, value := range header.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			existing = strings.TrimSpace(existing)
+			if existing == "*" || strings.EqualFold(existing, field) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", field)
+}
+
+// bodyAllowedForStatus reports whether a response with the given status may
+// include a body, as defined in RFC 9110.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package core_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response compression tests", func() {
+	body := strings.Repeat(`{"message":"hello world"}`, 100)
+	compression := core.WithCompression(core.Compression{})
+
+	request := func(acceptEncoding ...string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		for _, value := range acceptEncoding {
+			req.Header.Add("Accept-Encoding", value)
+		}
+		return req
+	}
+
+	decode := func(encoding, output string) string {
+		compressed, err := base64.StdEncoding.DecodeString(output)
+		Expect(err).To(BeNil())
+		var r io.Reader
+		if encoding == "br" {
+			r = brotli.NewReader(bytes.NewReader(compressed))
+		} else {
+			gr, err := gzip.NewReader(bytes.NewReader(compressed))
+			Expect(err).To(BeNil())
+			r = gr
+		}
+		decoded, err := io.ReadAll(r)
+		Expect(err).To(BeNil())
+		return string(decoded)
+	}
+
+	Context("Negotiation", func() {
+		for _, c := range []struct {
+			acceptEncoding []string
+			encoding       string
+		}{
+			{[]string{"gzip"}, "gzip"},
+			{[]string{"gzip, deflate, br"}, "br"},
+			{[]string{"gzip;q=1.0, br;q=0.5"}, "gzip"},
+			{[]string{"deflate", "br"}, "br"},
+			{[]string{"*"}, "br"},
+			{[]string{"br;q=0, *"}, "gzip"},
+			{[]string{"x-gzip"}, "gzip"},
+			{[]string{"gzip;q=0"}, ""},
+			{[]string{"identity"}, ""},
+			{nil, ""},
+		} {
+			c := c
+			It("Negotiates the coding for "+strings.Join(c.acceptEncoding, "|"), func() {
+				w := core.NewRequestAccessor(compression).NewProxyResponseWriter()
+				w.BindRequest(request(c.acceptEncoding...), time.Second)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(body))
+
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect(resp.MultiValueHeaders["Vary"]).To(Equal([]string{"Accept-Encoding"}))
+				if c.encoding == "" {
+					Expect(resp.MultiValueHeaders).ToNot(HaveKey("Content-Encoding"))
+					Expect(resp.IsBase64Encoded).To(BeFalse())
+					Expect(resp.Body).To(Equal(body))
+					return
+				}
+				Expect(resp.MultiValueHeaders["Content-Encoding"]).To(Equal([]string{c.encoding}))
+				Expect(resp.IsBase64Encoded).To(BeTrue())
+				Expect(decode(c.encoding, resp.Body)).To(Equal(body))
+			})
+		}
+	})
+
+	Context("Eligible responses", func() {
+		It("Compresses the responses of every writer", func() {
+			w2 := core.NewRequestAccessorV2(compression).NewProxyResponseWriterV2()
+			w2.BindRequest(request("gzip"), time.Second)
+			w2.Header().Set("Content-Type", "text/html")
+			w2.Header().Set("Content-Length", "2500")
+			w2.Header().Set("Vary", "Origin")
+			w2.Write([]byte(body))
+			resp2, err := w2.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp2.Headers["Content-Encoding"]).To(Equal("gzip"))
+			Expect(resp2.Headers["Vary"]).To(Equal("Origin,Accept-Encoding"))
+			Expect(resp2.Headers["Content-Length"]).To(Equal(strconv.Itoa(base64.StdEncoding.DecodedLen(len(resp2.Body)) - strings.Count(resp2.Body, "="))))
+			Expect(resp2.IsBase64Encoded).To(BeTrue())
+			Expect(decode("gzip", resp2.Body)).To(Equal(body))
+
+			wALB := core.NewRequestAccessorALB(compression).NewProxyResponseWriterALB()
+			wALB.BindRequest(request("br"), time.Second)
+			wALB.Header().Set("Content-Type", "application/json")
+			wALB.WriteHeader(http.StatusOK)
+			wALB.Write([]byte(body))
+			respALB, err := wALB.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(respALB.MultiValueHeaders["Content-Encoding"]).To(Equal([]string{"br"}))
+			Expect(decode("br", respALB.Body)).To(Equal(body))
+		})
+
+		It("Weakens strong entity tags of compressed responses", func() {
+			for etag, expected := range map[string]string{`"v1"`: `W/"v1"`, `W/"v1"`: `W/"v1"`} {
+				w := core.NewRequestAccessor(compression).NewProxyResponseWriter()
+				w.BindRequest(request("gzip"), time.Second)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", etag)
+				w.Write([]byte(body))
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect(resp.MultiValueHeaders["Content-Encoding"]).To(Equal([]string{"gzip"}))
+				Expect(resp.MultiValueHeaders["Etag"]).To(Equal([]string{expected}))
+			}
+
+			w := core.NewRequestAccessor(compression).NewProxyResponseWriter()
+			w.BindRequest(request(), time.Second)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(body))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders["Etag"]).To(Equal([]string{`"v1"`}))
+		})
+
+		It("Compresses even when the binary policy never encodes", func() {
+			accessor := core.NewRequestAccessor(compression, core.WithBinaryPolicy(core.BinaryPolicy{Mode: core.BinaryNever}))
+			w := accessor.NewProxyResponseWriter()
+			w.BindRequest(request("gzip"), time.Second)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.IsBase64Encoded).To(BeTrue())
+		})
+	})
+
+	Context("Skipped responses", func() {
+		for _, c := range []struct {
+			name    string
+			options []core.Option
+			header  map[string]string
+			status  int
+			body    string
+		}{
+			{"disabled compression", nil, map[string]string{"Content-Type": "application/json"}, http.StatusOK, body},
+			{"small bodies", []core.Option{compression}, map[string]string{"Content-Type": "application/json"}, http.StatusOK, "{}"},
+			{"bodies below the minimum size", []core.Option{core.WithCompression(core.Compression{MinSize: 10000})}, map[string]string{"Content-Type": "application/json"}, http.StatusOK, body},
+			{"other content types", []core.Option{compression}, map[string]string{"Content-Type": "image/png"}, http.StatusOK, body},
+			{"content types outside the allowlist", []core.Option{core.WithCompression(core.Compression{ContentTypes: []string{"text/*"}})}, map[string]string{"Content-Type": "application/json"}, http.StatusOK, body},
+			{"compressed responses", []core.Option{compression}, map[string]string{"Content-Type": "application/json", "Content-Encoding": "identity-ish"}, http.StatusOK, body},
+			{"event streams", []core.Option{compression}, map[string]string{"Content-Type": "text/event-stream"}, http.StatusOK, body},
+			{"no-transform responses", []core.Option{compression}, map[string]string{"Content-Type": "application/json", "Cache-Control": "public, no-transform"}, http.StatusOK, body},
+			{"not modified responses", []core.Option{compression}, map[string]string{"Content-Type": "application/json"}, http.StatusNotModified, ""},
+			{"partial content responses", []core.Option{compression}, map[string]string{"Content-Type": "application/json"}, http.StatusPartialContent, body},
+			{"responses with a content range", []core.Option{compression}, map[string]string{"Content-Type": "application/json", "Content-Range": "bytes 0-2499/5000"}, http.StatusOK, body},
+		} {
+			c := c
+			It("Does not compress "+c.name, func() {
+				w := core.NewRequestAccessor(c.options...).NewProxyResponseWriter()
+				w.BindRequest(request("gzip, br"), time.Second)
+				for k, v := range c.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(c.status)
+				w.Write([]byte(c.body))
+
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect(resp.IsBase64Encoded).To(BeFalse())
+				Expect(resp.Body).To(Equal(c.body))
+				if c.header["Content-Encoding"] == "" {
+					Expect(resp.MultiValueHeaders).ToNot(HaveKey("Content-Encoding"))
+				}
+			})
+		}
+	})
+})
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTimeoutReserve is the amount of time reserved before the Lambda
+// invocation deadline when a request context is derived with
+// NewInvocationContext. The reserve leaves the adapter enough time to produce
+// a response before Lambda terminates the invocation.
+const DefaultTimeoutReserve = 500 * time.Millisecond
+
+// NewInvocationContext derives a context from the Lambda invocation context.
+// If ctx carries a deadline, the returned context expires reserve before it;
+// otherwise the returned context only carries cancellation. A negative reserve
+// is treated as zero, as the deadline of ctx cannot be extended.
+// The returned cancel function must be called once the response is produced.
+func NewInvocationContext(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline.Add(-max(reserve, 0)))
+	}
+	return context.WithCancel(ctx)
+}
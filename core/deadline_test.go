@@ -0,0 +1,50 @@
+package core_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewInvocationContext tests", func() {
+	It("Sets the deadline ahead of the invocation deadline", func() {
+		deadline := time.Now().Add(10 * time.Second)
+		lambdaCtx, lambdaCancel := context.WithDeadline(context.Background(), deadline)
+		defer lambdaCancel()
+
+		ctx, cancel := core.NewInvocationContext(lambdaCtx, time.Second)
+		defer cancel()
+
+		reqDeadline, ok := ctx.Deadline()
+		Expect(ok).To(BeTrue())
+		Expect(reqDeadline).To(Equal(deadline.Add(-time.Second)))
+	})
+
+	It("Does not extend the invocation deadline with a negative reserve", func() {
+		deadline := time.Now().Add(10 * time.Second)
+		lambdaCtx, lambdaCancel := context.WithDeadline(context.Background(), deadline)
+		defer lambdaCancel()
+
+		ctx, cancel := core.NewInvocationContext(lambdaCtx, -time.Second)
+		defer cancel()
+
+		reqDeadline, ok := ctx.Deadline()
+		Expect(ok).To(BeTrue())
+		Expect(reqDeadline).To(Equal(deadline))
+	})
+
+	It("Only adds cancellation without an invocation deadline", func() {
+		ctx, cancel := core.NewInvocationContext(context.Background(), time.Second)
+
+		_, ok := ctx.Deadline()
+		Expect(ok).To(BeFalse())
+		Expect(ctx.Err()).To(BeNil())
+
+		cancel()
+		Expect(ctx.Err()).To(Equal(context.Canceled))
+	})
+})
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultMaxDecompressedBodySize is the maximum size of a decompressed request
+// body used when body decompression is enabled without an explicit limit.
+const DefaultMaxDecompressedBodySize = 10 << 20
+
+// ErrDecompressedBodyTooLarge is returned when the decompressed request body
+// of an event exceeds the configured maximum size. It matches
+// ErrRequestBodyTooLarge with errors.Is, so that the request is answered with
+// a 413 response.
+var ErrDecompressedBodyTooLarge = fmt.Errorf("decompressed %w", ErrRequestBodyTooLarge)
+
+// decompressRequestBody replaces the body of req with its content decoded
+// from the codings listed in its Content-Encoding header. The Content-Encoding
+// header is removed and ContentLength and the Content-Length header are set to
+// the decompressed length. Requests using an unsupported coding are left
+// untouched. Returns ErrDecompressedBodyTooLarge if the body decompresses to
+// more than maxSize bytes and an InvalidEventError if it cannot be decoded.
+// maxSize must be positive.
+func decompressRequestBody(req *http.Request, maxSize int64) error {
+	encodings := contentEncodings(req.Header)
+	if len(encodings) == 0 {
+		return nil
+	}
+	for _, encoding := range encodings {
+		if !supportedContentEncoding(encoding) {
+			return nil
+		}
+	}
+
+	d := newDecompressingReader(req.Body, encodings, maxSize)
+	body, err := io.ReadAll(d)
+	d.Close()
+	if errors.Is(err, ErrDecompressedBodyTooLarge) {
+		return fmt.Errorf("%w: more than %d bytes", err, maxSize)
+	}
+	if err != nil {
+		return &InvalidEventError{Field: "body", Err: &DecodingError{Name: "compressed body", Err: err}}
+	}
+
+	req.ContentLength = int64(len(body))
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	if len(body) == 0 {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+	}
+	req.Header.Del("Content-Encoding")
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// contentEncodings returns the codings listed in the Content-Encoding header,
+// in the order in which they were applied, ignoring identity.
+func contentEncodings(header http.Header) []string {
+	var encodings []string
+	for _, value := range header.Values("Content-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding == "" || encoding == "identity" {
+				continue
+			}
+			encodings = append(encodings, encoding)
+		}
+	}
+	return encodings
+}
+
+func supportedContentEncoding(encoding string) bool {
+	switch encoding {
+	case "gzip", "x-gzip", "deflate", "br":
+		return true
+	}
+	return false
+}
+
+// decompressingReader decodes a request body and fails with
+// ErrDecompressedBodyTooLarge once more than max bytes are produced.
+type decompressingReader struct {
+	body      io.ReadCloser
+	encodings []string
+	max       int64
+	read      int64
+	r         io.Reader
+	closers   []io.Closer
+	err       error
+}
+
+func newDecompressingReader(body io.ReadCloser, encodings []string, max int64) *decompressingReader {
+	return &decompressingReader{body: body, encodings: encodings, max: max}
+}
+
+func (d *decompressingReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.r == nil {
+		if err := d.init(); err != nil {
+			d.err = err
+			return 0, err
+		}
+	}
+
+	n, err := d.r.Read(p)
+	d.read += int64(n)
+	if d.read > d.max {
+		d.err = ErrDecompressedBodyTooLarge
+		return n - int(d.read-d.max), d.err
+	}
+	return n, err
+}
+
+// init builds the decoder chain, undoing the codings in the reverse order in
+// which they were applied.
+func (d *decompressingReader) init() error {
+	var r io.Reader = d.body
+	for i := len(d.encodings) - 1; i >= 0; i-- {
+		switch d.encodings[i] {
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				return err
+			}
+			d.closers = append(d.closers, zr)
+			r = zr
+		case "deflate":
+			zr, err := newDeflateReader(r)
+			if err != nil {
+				return err
+			}
+			d.closers = append(d.closers, zr)
+			r = zr
+		case "br":
+			r = brotli.NewReader(r)
+		}
+	}
+	d.r = r
+	return nil
+}
+
+func (d *decompressingReader) Close() error {
+	for _, c := range d.closers {
+		c.Close()
+	}
+	return d.body.Close()
+}
+
+// newDeflateReader returns a reader for the deflate coding. RFC 9110 defines
+// deflate as a zlib stream, but some clients send raw deflate data so the zlib
+// header is checked before choosing a decoder.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,181 @@
+package core_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request body decompression tests", func() {
+	body := `{"message":"hello compressed world"}`
+
+	compress := func(newWriter func(io.Writer) io.WriteCloser, data []byte) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, err := w.Write(data)
+		Expect(err).To(BeNil())
+		Expect(w.Close()).To(BeNil())
+		return buf.Bytes()
+	}
+	gzipWriter := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	zlibWriter := func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+	flateWriter := func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	}
+	brotliWriter := func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }
+
+	compressedRequest := func(encoding string, data []byte) events.APIGatewayProxyRequest {
+		req := getProxyRequest("/hello", "POST")
+		req.Headers = map[string]string{
+			"Content-Type":     "application/json",
+			"Content-Encoding": encoding,
+		}
+		req.Body = base64.StdEncoding.EncodeToString(data)
+		req.IsBase64Encoded = true
+		return req
+	}
+
+	decompressingAccessor := func(maxSize int64) core.RequestAccessor {
+		accessor := core.RequestAccessor{}
+		accessor.EnableBodyDecompression(maxSize)
+		return accessor
+	}
+
+	codings := []struct {
+		name      string
+		encoding  string
+		newWriter func(io.Writer) io.WriteCloser
+	}{
+		{"gzip", "gzip", gzipWriter},
+		{"deflate", "deflate", zlibWriter},
+		{"raw deflate", "deflate", flateWriter},
+		{"brotli", "br", brotliWriter},
+	}
+	for _, c := range codings {
+		c := c
+		It("Decodes "+c.name+" bodies", func() {
+			accessor := decompressingAccessor(0)
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), compressedRequest(c.encoding, compress(c.newWriter, []byte(body))))
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Get("Content-Encoding")).To(Equal(""))
+			Expect(httpReq.ContentLength).To(Equal(int64(len(body))))
+			Expect(httpReq.Header.Get("Content-Length")).To(Equal(strconv.Itoa(len(body))))
+
+			bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(string(bodyBytes)).To(Equal(body))
+
+			replay, err := httpReq.GetBody()
+			Expect(err).To(BeNil())
+			bodyBytes, err = ioutil.ReadAll(replay)
+			Expect(err).To(BeNil())
+			Expect(string(bodyBytes)).To(Equal(body))
+		})
+	}
+
+	It("Decodes multiple codings in reverse order", func() {
+		data := compress(brotliWriter, compress(gzipWriter, []byte(body)))
+		accessor := decompressingAccessor(0)
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), compressedRequest("gzip, br", data))
+		Expect(err).To(BeNil())
+
+		bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+		Expect(err).To(BeNil())
+		Expect(string(bodyBytes)).To(Equal(body))
+	})
+
+	It("Enforces the decompressed size limit", func() {
+		bomb := compress(gzipWriter, []byte(strings.Repeat("a", 1<<20)))
+		accessor := decompressingAccessor(1024)
+		_, err := accessor.EventToRequestWithContext(context.Background(), compressedRequest("gzip", bomb))
+		Expect(errors.Is(err, core.ErrDecompressedBodyTooLarge)).To(BeTrue())
+		Expect(errors.Is(err, core.ErrRequestBodyTooLarge)).To(BeTrue())
+		Expect(core.ErrorStatusCode(err)).To(Equal(http.StatusRequestEntityTooLarge))
+	})
+
+	It("Enforces the maximum body size on the decompressed body", func() {
+		data := compress(gzipWriter, []byte(strings.Repeat("a", 1<<20)))
+		Expect(len(data)).To(BeNumerically("<", 4096))
+		accessor := core.NewRequestAccessor(
+			core.WithMaxBodySize(4096),
+			core.WithBodyDecompression(0),
+			core.WithBodyTooLargeResponse("text/plain", "too large"),
+		)
+		req := compressedRequest("gzip", data)
+		_, err := accessor.EventToRequestWithContext(context.Background(), req)
+		Expect(errors.Is(err, core.ErrDecompressedBodyTooLarge)).To(BeTrue())
+
+		resp, err := accessor.ErrorResponse(context.Background(), req, err)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(resp.Body).To(Equal("too large"))
+
+		perRoute := core.NewRequestAccessor(
+			core.WithBodyDecompression(0),
+			core.WithMaxBodySizeFunc(func(req *http.Request, limit int64) int64 { return 4096 }),
+		)
+		_, err = perRoute.EventToRequestWithContext(context.Background(), req)
+		Expect(errors.Is(err, core.ErrDecompressedBodyTooLarge)).To(BeTrue())
+	})
+
+	It("Rejects bodies that cannot be decompressed", func() {
+		accessor := decompressingAccessor(0)
+		_, err := accessor.EventToRequestWithContext(context.Background(), compressedRequest("gzip", []byte(body)))
+		Expect(core.IsInvalidEvent(err)).To(BeTrue())
+		Expect(core.ErrorStatusCode(err)).To(Equal(http.StatusBadRequest))
+	})
+
+	It("Leaves bodies untouched when decompression is not enabled", func() {
+		data := compress(gzipWriter, []byte(body))
+		accessor := core.RequestAccessor{}
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), compressedRequest("gzip", data))
+		Expect(err).To(BeNil())
+		Expect(httpReq.Header.Get("Content-Encoding")).To(Equal("gzip"))
+
+		bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+		Expect(err).To(BeNil())
+		Expect(bodyBytes).To(Equal(data))
+	})
+
+	It("Leaves bodies with unsupported codings untouched", func() {
+		accessor := decompressingAccessor(0)
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), compressedRequest("compress", []byte(body)))
+		Expect(err).To(BeNil())
+		Expect(httpReq.Header.Get("Content-Encoding")).To(Equal("compress"))
+		Expect(httpReq.ContentLength).To(Equal(int64(len(body))))
+	})
+
+	It("Decodes compressed bodies of API Gateway V2 events", func() {
+		accessor := core.RequestAccessorV2{}
+		accessor.EnableBodyDecompression(0)
+		req := getProxyRequestV2("/hello", "POST")
+		req.Headers = map[string]string{"content-encoding": "gzip"}
+		req.Body = base64.StdEncoding.EncodeToString(compress(gzipWriter, []byte(body)))
+		req.IsBase64Encoded = true
+
+		httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+		Expect(err).To(BeNil())
+		Expect(httpReq.Header.Get("Content-Encoding")).To(Equal(""))
+
+		bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+		Expect(err).To(BeNil())
+		Expect(string(bodyBytes)).To(Equal(body))
+	})
+})
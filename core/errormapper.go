@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// problemContentType is the media type of RFC 9457 problem details.
+const problemContentType = "application/problem+json"
+
+// ErrorMapper produces the responses to the errors of the adapters. MapError
+// writes the response to err to w, a response writer of the accessor that
+// produces it in the format of the event. ctx is the context of the Lambda
+// invocation.
+//
+// The errors are ConversionError for events that cannot be converted, wrapping
+// an InvalidEventError for malformed events or a RequestBodyTooLargeError for
+// bodies exceeding the maximum size, ResponseError for handler responses
+// that cannot be returned, PanicError for handlers that panicked,
+// TimeoutError for handlers that did not complete before the invocation
+// deadline, and otherwise failures of the adapter or of its configuration.
+// ErrorStatusCode returns the status code of the default responses.
+type ErrorMapper interface {
+	MapError(ctx context.Context, w http.ResponseWriter, err error)
+}
+
+// ErrorMapperFunc is an adapter to use an ordinary function as an ErrorMapper.
+type ErrorMapperFunc func(ctx context.Context, w http.ResponseWriter, err error)
+
+// MapError calls f(ctx, w, err).
+func (f ErrorMapperFunc) MapError(ctx context.Context, w http.ResponseWriter, err error) {
+	f(ctx, w, err)
+}
+
+// ResponseError is passed to the error mapper when the response written by the
+// handler cannot be converted into a proxy response, for example because its
+// status code is invalid.
+type ResponseError struct {
+	Err error
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("invalid proxy response: %v", e.Err)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorStatusCode returns the status code of the response to an error of an
+// adapter: 400 Bad Request for invalid events, 413 Request Entity Too Large
+// for bodies exceeding the maximum size, 502 Bad Gateway for handler responses
+// that cannot be returned, 504 Gateway Timeout for handlers that did not
+// complete in time and 500 Internal Server Error otherwise, including for
+// handlers that panicked.
+func ErrorStatusCode(err error) int {
+	var responseErr *ResponseError
+	var timeoutErr *TimeoutError
+	switch {
+	case errors.Is(err, ErrRequestBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case IsInvalidEvent(err):
+		return http.StatusBadRequest
+	case errors.As(err, &responseErr):
+		return http.StatusBadGateway
+	case errors.As(err, &timeoutErr):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// problemDetails is an RFC 9457 problem details object. The requestId
+// extension member holds the request ID of the Lambda invocation.
+type problemDetails struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// ProblemDetailsMapper is the default ErrorMapper. It answers errors with an
+// RFC 9457 application/problem+json body with the status code returned by
+// ErrorStatusCode and the request ID of the Lambda invocation. The error is
+// described to the client only when it was caused by the event; the details
+// of other failures are only logged.
+type ProblemDetailsMapper struct{}
+
+// MapError implements the ErrorMapper interface.
+func (ProblemDetailsMapper) MapError(ctx context.Context, w http.ResponseWriter, err error) {
+	status := ErrorStatusCode(err)
+	problem := problemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if status < http.StatusInternalServerError {
+		problem.Detail = err.Error()
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		problem.RequestID = lc.AwsRequestID
+	}
+
+	body, _ := json.Marshal(problem)
+	w.Header().Set(contentTypeHeaderKey, problemContentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeError logs err, unless it is a recovered panic, and writes the
+// response to it to w with the error mapper. The response set with
+// WithBodyTooLargeResponse takes precedence for bodies exceeding the maximum
+// size. eventType and routeKey describe the event in the log record.
+func (o *options) writeError(ctx context.Context, eventType, routeKey string, w http.ResponseWriter, err error) {
+	logger := o.eventLogger(ctx, eventType, routeKey)
+	var responseErr *ResponseError
+	var panicErr *PanicError
+	var timeoutErr *TimeoutError
+	switch {
+	case errors.As(err, &panicErr):
+		// logged with its stack when recovered
+	case errors.As(err, &timeoutErr):
+		logger.Error("Handler did not complete before the invocation deadline", "error", err)
+	case errors.Is(err, ErrRequestBodyTooLarge):
+		logger.Warn("Rejected proxy event", "error", err)
+	case IsInvalidEvent(err):
+		logger.Warn("Rejected invalid proxy event", "error", err)
+	case errors.As(err, &responseErr):
+		logger.Error("Error while generating proxy response", "error", responseErr.Err)
+	default:
+		logger.Error("Could not proxy event", "error", err)
+	}
+
+	if errors.Is(err, ErrRequestBodyTooLarge) && (o.bodyTooLargeType != "" || o.bodyTooLargeBody != "") {
+		w.Header().Set(contentTypeHeaderKey, o.bodyTooLargeType)
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		io.WriteString(w, o.bodyTooLargeBody)
+		return
+	}
+
+	mapper := o.errorMapper
+	if mapper == nil {
+		mapper = ProblemDetailsMapper{}
+	}
+	mapper.MapError(ctx, w, err)
+}
@@ -0,0 +1,121 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error mapper tests", func() {
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "8476a536-e9f4-11e8-9739-2dfe598c3fcd"})
+
+	problem := func(body string) map[string]interface{} {
+		var p map[string]interface{}
+		Expect(json.Unmarshal([]byte(body), &p)).To(BeNil())
+		return p
+	}
+
+	Context("Status codes", func() {
+		for _, c := range []struct {
+			name   string
+			err    error
+			status int
+		}{
+			{"invalid events", &core.InvalidEventError{Field: "body", Err: errors.New("illegal base64 data")}, http.StatusBadRequest},
+			{"oversized bodies", &core.RequestBodyTooLargeError{Size: 5, Limit: 4}, http.StatusRequestEntityTooLarge},
+			{"invalid responses", &core.ResponseError{Err: core.ErrStatusNotSet}, http.StatusBadGateway},
+			{"other failures", errors.New("boom"), http.StatusInternalServerError},
+		} {
+			c := c
+			It("Maps "+c.name+" to "+http.StatusText(c.status), func() {
+				Expect(core.ErrorStatusCode(c.err)).To(Equal(c.status))
+			})
+		}
+	})
+
+	Context("Problem details", func() {
+		It("Answers errors with problem+json in every event format", func() {
+			err := &core.InvalidEventError{Field: "body", Err: errors.New("illegal base64 data")}
+
+			resp, respErr := core.NewRequestAccessor().ErrorResponse(ctx, getProxyRequest("/orders", "GET"), err)
+			Expect(respErr).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+			Expect(problem(resp.Body)).To(Equal(map[string]interface{}{
+				"type":      "about:blank",
+				"title":     "Bad Request",
+				"status":    float64(400),
+				"detail":    "invalid body in event: illegal base64 data",
+				"requestId": "8476a536-e9f4-11e8-9739-2dfe598c3fcd",
+			}))
+
+			respV2, respErr := core.NewRequestAccessorV2().ErrorResponse(ctx, getProxyRequestV2("/orders", "GET"), err)
+			Expect(respErr).To(BeNil())
+			Expect(respV2.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(respV2.Headers["Content-Type"]).To(Equal("application/problem+json"))
+			Expect(respV2.Body).To(Equal(resp.Body))
+
+			single := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, map[string]string{"accept": "*/*"}, "", nil, nil, nil)
+			respALB, respErr := core.NewRequestAccessorALB().ErrorResponse(ctx, single, err)
+			Expect(respErr).To(BeNil())
+			Expect(respALB.StatusDescription).To(Equal("400 Bad Request"))
+			Expect(respALB.Headers["Content-Type"]).To(Equal("application/problem+json"))
+			Expect(respALB.MultiValueHeaders).To(BeNil())
+			Expect(respALB.Body).To(Equal(resp.Body))
+
+			multi := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, nil, "", nil, map[string][]string{"accept": {"*/*"}}, nil)
+			respALB, respErr = core.NewRequestAccessorALB().ErrorResponse(ctx, multi, err)
+			Expect(respErr).To(BeNil())
+			Expect(respALB.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+			Expect(respALB.Headers).To(BeNil())
+		})
+
+		It("Does not describe failures of the function to the client", func() {
+			resp, err := core.NewRequestAccessor().ErrorResponse(ctx, getProxyRequest("/orders", "GET"), &core.ResponseError{Err: core.ErrStatusNotSet})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(problem(resp.Body)).ToNot(HaveKey("detail"))
+			Expect(problem(resp.Body)["requestId"]).To(Equal("8476a536-e9f4-11e8-9739-2dfe598c3fcd"))
+
+			resp, err = core.NewRequestAccessor().ErrorResponse(context.Background(), getProxyRequest("/orders", "GET"), errors.New("boom"))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(problem(resp.Body)).ToNot(HaveKey("requestId"))
+		})
+	})
+
+	Context("Custom mappers", func() {
+		It("Uses the mapper set with WithErrorMapper", func() {
+			var mapped error
+			mapper := core.WithErrorMapper(core.ErrorMapperFunc(func(ctx context.Context, w http.ResponseWriter, err error) {
+				mapped = err
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("try again later"))
+			}))
+			err := errors.New("boom")
+
+			respV2, respErr := core.NewRequestAccessorV2(mapper).ErrorResponse(ctx, getProxyRequestV2("/orders", "GET"), err)
+			Expect(respErr).To(BeNil())
+			Expect(mapped).To(Equal(err))
+			Expect(respV2.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(respV2.Headers["Content-Type"]).To(Equal("text/plain"))
+			Expect(respV2.Body).To(Equal("try again later"))
+		})
+
+		It("Returns an error when the mapper writes no response", func() {
+			mapper := core.WithErrorMapper(core.ErrorMapperFunc(func(ctx context.Context, w http.ResponseWriter, err error) {}))
+
+			resp, err := core.NewRequestAccessor(mapper).ErrorResponse(ctx, getProxyRequest("/orders", "GET"), errors.New("boom"))
+			Expect(err).ToNot(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+		})
+	})
+})
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrStatusNotSet is returned by GetProxyResponse when the handler did
+	// not write a status code or a body.
+	ErrStatusNotSet = errors.New("status code not set on response")
+
+	// ErrInvalidStatusCode is returned by GetProxyResponse when the handler
+	// wrote a status code outside 100-599.
+	ErrInvalidStatusCode = errors.New("invalid status code")
+
+	// ErrNoContextHeader is returned when a request converted without a
+	// context has no context header.
+	ErrNoContextHeader = errors.New("no context header in request")
+
+	// ErrNoStageVarsHeader is returned when a request converted without a
+	// context has no stage variables header.
+	ErrNoStageVarsHeader = errors.New("no stage vars header in request")
+
+	// ErrUnknownVersion is returned when the payload version of a switchable
+	// request or response cannot be determined.
+	ErrUnknownVersion = errors.New("unable to determine payload version")
+)
+
+// ConversionError is returned by the accessors when a proxy event cannot be
+// converted into an http.Request. It wraps the cause, such as an
+// InvalidEventError for malformed events or a RequestBodyTooLargeError.
+type ConversionError struct {
+	Err error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("could not convert proxy event to request: %v", e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// DecodingError is returned when an encoded value cannot be decoded, such as
+// the base64 body of an event or the JSON of a context header.
+type DecodingError struct {
+	// Name describes the decoded value, such as "base64 body".
+	Name string
+	// Err is the error of the decoder.
+	Err error
+}
+
+func (e *DecodingError) Error() string {
+	return fmt.Sprintf("cannot decode %s: %v", e.Name, e.Err)
+}
+
+func (e *DecodingError) Unwrap() error {
+	return e.Err
+}
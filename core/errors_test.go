@@ -0,0 +1,124 @@
+package core_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error type tests", func() {
+	Context("Conversion errors", func() {
+		It("Wraps the cause of failed conversions", func() {
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "not base64!"
+			req.IsBase64Encoded = true
+			_, errV1 := core.NewRequestAccessor().EventToRequest(req)
+
+			reqV2 := getProxyRequestV2("/orders", "POST")
+			reqV2.Body = "not base64!"
+			reqV2.IsBase64Encoded = true
+			_, errV2 := core.NewRequestAccessorV2().EventToRequest(reqV2)
+
+			reqALB := getALBProxyRequest("/orders", "POST", getALBRequestContext(), false, nil, "not base64!", nil, nil, nil)
+			reqALB.IsBase64Encoded = true
+			_, errALB := core.NewRequestAccessorALB().EventToRequest(reqALB)
+
+			for _, err := range []error{errV1, errV2, errALB} {
+				var convErr *core.ConversionError
+				Expect(errors.As(err, &convErr)).To(BeTrue())
+				Expect(core.IsInvalidEvent(err)).To(BeTrue())
+
+				var decErr *core.DecodingError
+				Expect(errors.As(err, &decErr)).To(BeTrue())
+				Expect(decErr.Name).To(Equal("base64 body"))
+
+				var corrupt base64.CorruptInputError
+				Expect(errors.As(err, &corrupt)).To(BeTrue())
+			}
+		})
+
+		It("Wraps oversized bodies", func() {
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "hello"
+			_, err := core.NewRequestAccessor(core.WithMaxBodySize(4)).ProxyEventToHTTPRequest(req)
+
+			var convErr *core.ConversionError
+			Expect(errors.As(err, &convErr)).To(BeTrue())
+			Expect(errors.Is(err, core.ErrRequestBodyTooLarge)).To(BeTrue())
+		})
+	})
+
+	Context("Context headers", func() {
+		It("Reports missing headers", func() {
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+
+			_, err := core.NewRequestAccessor().GetAPIGatewayContext(req)
+			Expect(err).To(MatchError(core.ErrNoContextHeader))
+			_, err = core.NewRequestAccessor().GetAPIGatewayStageVars(req)
+			Expect(err).To(MatchError(core.ErrNoStageVarsHeader))
+
+			_, err = core.NewRequestAccessorV2().GetAPIGatewayContextV2(req)
+			Expect(err).To(MatchError(core.ErrNoContextHeader))
+			_, err = core.NewRequestAccessorV2().GetAPIGatewayStageVars(req)
+			Expect(err).To(MatchError(core.ErrNoStageVarsHeader))
+
+			_, err = core.NewRequestAccessorALB().GetContextALB(req)
+			Expect(err).To(MatchError(core.ErrNoContextHeader))
+		})
+
+		It("Reports headers that cannot be decoded", func() {
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set(core.APIGwContextHeader, "{")
+			req.Header.Set(core.APIGwStageVarsHeader, "{")
+			req.Header.Set(core.ALBContextHeader, "{")
+
+			_, errV1 := core.NewRequestAccessor().GetAPIGatewayContext(req)
+			_, errStageVars := core.NewRequestAccessor().GetAPIGatewayStageVars(req)
+			_, errV2 := core.NewRequestAccessorV2().GetAPIGatewayContextV2(req)
+			_, errALB := core.NewRequestAccessorALB().GetContextALB(req)
+
+			for _, err := range []error{errV1, errStageVars, errV2, errALB} {
+				var decErr *core.DecodingError
+				Expect(errors.As(err, &decErr)).To(BeTrue())
+				Expect(decErr.Name).To(HaveSuffix(" header"))
+
+				var syntaxErr *json.SyntaxError
+				Expect(errors.As(err, &syntaxErr)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("Response errors", func() {
+		It("Reports invalid status codes", func() {
+			w := core.NewProxyResponseWriter()
+			w.WriteHeader(42)
+			_, err := w.GetProxyResponse()
+			Expect(errors.Is(err, core.ErrInvalidStatusCode)).To(BeTrue())
+
+			wV2 := core.NewProxyResponseWriterV2()
+			wV2.WriteHeader(600)
+			_, err = wV2.GetProxyResponse()
+			Expect(errors.Is(err, core.ErrInvalidStatusCode)).To(BeTrue())
+
+			wALB := core.NewProxyResponseWriterALB()
+			wALB.WriteHeader(42)
+			_, err = wALB.GetProxyResponse()
+			Expect(errors.Is(err, core.ErrInvalidStatusCode)).To(BeTrue())
+		})
+
+		It("Reports payloads of unknown versions", func() {
+			var req core.SwitchableAPIGatewayRequest
+			Expect(json.Unmarshal([]byte(`{}`), &req)).To(MatchError(core.ErrUnknownVersion))
+
+			var resp core.SwitchableAPIGatewayResponse
+			Expect(json.Unmarshal([]byte(`{}`), &resp)).To(MatchError(core.ErrUnknownVersion))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// Event types reported in the eventType attribute of log records.
+const (
+	eventTypeAPIGateway   = "APIGatewayProxyRequest"
+	eventTypeAPIGatewayV2 = "APIGatewayV2HTTPRequest"
+	eventTypeALB          = "ALBTargetGroupRequest"
+)
+
+// logInfoKey is the context key of the event attributes of log records.
+type logInfoKey struct{}
+
+// logInfo describes the event a request was converted from in log records.
+type logInfo struct {
+	eventType string
+	routeKey  string
+}
+
+// withLogInfo stores the event type and route key of req in its context.
+func withLogInfo(req *http.Request, eventType, routeKey string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), logInfoKey{}, logInfo{eventType: eventType, routeKey: routeKey}))
+}
+
+// copyContextValues copies the public path prefix and the event attributes of
+// log records stored in the context of from to the context of to.
+func copyContextValues(from, to *http.Request) *http.Request {
+	to = copyBasePath(from, to)
+	if info, ok := from.Context().Value(logInfoKey{}).(logInfo); ok {
+		return to.WithContext(context.WithValue(to.Context(), logInfoKey{}, info))
+	}
+	return to
+}
+
+// Logger returns the logger set with WithLogger, or the default slog logger.
+func (o responseOptions) Logger() *slog.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return slog.Default()
+}
+
+// RequestLogger returns the logger with the attributes of the event req was
+// converted from: the request ID of the Lambda invocation, the route key and
+// the event type. The route key of requests that were not converted from an
+// event is their method and path.
+func (o responseOptions) RequestLogger(req *http.Request) *slog.Logger {
+	return o.routeLogger(req.Context(), req.Method+" "+req.URL.Path)
+}
+
+// eventLogger returns the logger with the attributes of an event: the
+// request ID of the Lambda invocation in ctx, the route key and the event
+// type.
+func (o responseOptions) eventLogger(ctx context.Context, eventType, routeKey string) *slog.Logger {
+	var attrs []any
+	if ctx != nil {
+		if lc, ok := lambdacontext.FromContext(ctx); ok {
+			attrs = append(attrs, slog.String("requestId", lc.AwsRequestID))
+		}
+	}
+	if routeKey != "" {
+		attrs = append(attrs, slog.String("routeKey", routeKey))
+	}
+	if eventType != "" {
+		attrs = append(attrs, slog.String("eventType", eventType))
+	}
+	return o.Logger().With(attrs...)
+}
+
+// contextLogger returns the logger with the attributes of the event of the
+// request whose context is ctx. ctx may be nil.
+func (o responseOptions) contextLogger(ctx context.Context) *slog.Logger {
+	return o.routeLogger(ctx, "")
+}
+
+// routeLogger is like contextLogger, but uses route, the method and path of
+// the request, as the route key when the request was not converted from an
+// event.
+func (o responseOptions) routeLogger(ctx context.Context, route string) *slog.Logger {
+	var info logInfo
+	if ctx != nil {
+		info, _ = ctx.Value(logInfoKey{}).(logInfo)
+	}
+	if info.routeKey == "" {
+		info.routeKey = route
+	}
+	return o.eventLogger(ctx, info.eventType, info.routeKey)
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// remappedHeaderPrefix is the prefix API Gateway REST APIs add to the names
+// of headers that conflict with the ones it manages itself.
+const remappedHeaderPrefix = "X-Amzn-Remapped-"
+
+// normalizeRequest smooths over the differences between a request converted
+// from an event and one received directly by a net/http server: remapped
+// headers get their original name back, the Content-Length header of requests
+// with a body matches the decoded body, Host is populated from the URL or the
+// Host header, and the protocol version is taken from the event when it has
+// one.
+func normalizeRequest(req *http.Request, protocol string) {
+	restoreRemappedHeaders(req.Header)
+
+	if req.URL.Host == "" {
+		req.URL.Host = req.Header.Get("Host")
+	}
+	req.Host = req.URL.Host
+
+	if req.ContentLength > 0 {
+		req.Header.Set("Content-Length", strconv.FormatInt(req.ContentLength, 10))
+		req.Header.Del("Transfer-Encoding")
+	}
+
+	setProto(req, protocol)
+}
+
+// restoreRemappedHeaders renames x-amzn-Remapped-* headers back to their
+// original name unless a header with that name is also present.
+func restoreRemappedHeaders(header http.Header) {
+	for key, values := range header {
+		if !strings.HasPrefix(key, remappedHeaderPrefix) || len(key) == len(remappedHeaderPrefix) {
+			continue
+		}
+		original := key[len(remappedHeaderPrefix):]
+		if _, ok := header[original]; !ok {
+			header[original] = values
+		}
+		delete(header, key)
+	}
+}
+
+// setProto sets the protocol version of req from the protocol of the event,
+// such as HTTP/1.1 or HTTP/2.0. Unknown values leave the HTTP/1.1 default set
+// by http.NewRequest in place.
+func setProto(req *http.Request, protocol string) {
+	if protocol == "" {
+		return
+	}
+	if protocol == "HTTP/2" {
+		protocol = "HTTP/2.0"
+	}
+	major, minor, ok := http.ParseHTTPVersion(protocol)
+	if !ok {
+		return
+	}
+	req.Proto = protocol
+	req.ProtoMajor = major
+	req.ProtoMinor = minor
+}
@@ -0,0 +1,117 @@
+package core_test
+
+import (
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request normalisation tests", func() {
+	Context("Remapped headers", func() {
+		It("Restores remapped headers", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.MultiValueHeaders = map[string][]string{
+				"x-amzn-Remapped-Authorization": {"Bearer token"},
+				"x-amzn-Remapped-Date":          {"Wed, 21 Oct 2015 07:28:00 GMT"},
+				"Date":                          {"Thu, 22 Oct 2015 07:28:00 GMT"},
+			}
+
+			httpReq, err := core.NewRequestAccessor().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Get("Authorization")).To(Equal("Bearer token"))
+			Expect(httpReq.Header.Get("Date")).To(Equal("Thu, 22 Oct 2015 07:28:00 GMT"))
+			Expect(httpReq.Header).ToNot(HaveKey("X-Amzn-Remapped-Authorization"))
+			Expect(httpReq.Header).ToNot(HaveKey("X-Amzn-Remapped-Date"))
+		})
+	})
+
+	Context("Content-Length", func() {
+		It("Sets Content-Length from the decoded body", func() {
+			req := getProxyRequestV2("/orders", "POST")
+			req.Body = "aGVsbG8gd29ybGQ="
+			req.IsBase64Encoded = true
+			req.Headers = map[string]string{
+				"content-length":    "16",
+				"transfer-encoding": "chunked",
+			}
+
+			httpReq, err := core.NewRequestAccessorV2().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.ContentLength).To(Equal(int64(11)))
+			Expect(httpReq.Header.Get("Content-Length")).To(Equal("11"))
+			Expect(httpReq.Header).ToNot(HaveKey("Transfer-Encoding"))
+		})
+
+		It("Adds a missing Content-Length", func() {
+			req := getALBProxyRequest("/orders", "POST", getALBRequestContext(), false, nil, "hello", nil, nil, nil)
+
+			httpReq, err := core.NewRequestAccessorALB().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Get("Content-Length")).To(Equal("5"))
+		})
+
+		It("Does not add Content-Length to requests without a body", func() {
+			httpReq, err := core.NewRequestAccessor().EventToRequest(getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(httpReq.ContentLength).To(Equal(int64(0)))
+			Expect(httpReq.Header).ToNot(HaveKey("Content-Length"))
+		})
+	})
+
+	Context("Host", func() {
+		It("Uses the domain name of the request context", func() {
+			req := getProxyRequestV2("/orders", "GET")
+			req.RequestContext.DomainName = "api.example.com"
+
+			httpReq, err := core.NewRequestAccessorV2().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Host).To(Equal("api.example.com"))
+			Expect(httpReq.URL.Host).To(Equal("api.example.com"))
+		})
+
+		It("Falls back to the Host header", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.Headers = map[string]string{"Host": "api.example.com"}
+
+			httpReq, err := core.NewRequestAccessor().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Host).To(Equal("api.example.com"))
+			Expect(httpReq.URL.String()).To(Equal("https://api.example.com/orders"))
+		})
+	})
+
+	Context("Protocol", func() {
+		It("Sets the protocol of REST API events", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.RequestContext.Protocol = "HTTP/1.0"
+
+			httpReq, err := core.NewRequestAccessor().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Proto).To(Equal("HTTP/1.0"))
+			Expect(httpReq.ProtoMajor).To(Equal(1))
+			Expect(httpReq.ProtoMinor).To(Equal(0))
+		})
+
+		It("Sets the protocol of HTTP API events", func() {
+			req := getProxyRequestV2("/orders", "GET")
+			req.RequestContext.HTTP.Protocol = "HTTP/2"
+
+			httpReq, err := core.NewRequestAccessorV2().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Proto).To(Equal("HTTP/2.0"))
+			Expect(httpReq.ProtoMajor).To(Equal(2))
+			Expect(httpReq.ProtoMinor).To(Equal(0))
+		})
+
+		It("Defaults to HTTP/1.1", func() {
+			req := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, nil, "", nil, nil, nil)
+
+			httpReq, err := core.NewRequestAccessorALB().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Proto).To(Equal("HTTP/1.1"))
+			Expect(httpReq.ProtoMajor).To(Equal(1))
+			Expect(httpReq.ProtoMinor).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,410 @@
+package core
+
+import (
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// Option configures the request conversion of a RequestAccessor,
+// RequestAccessorV2 or RequestAccessorALB object, and of the adapters that
+// embed them. The same options are honoured by all three event types.
+type Option func(*options)
+
+// options holds the configuration shared by the request accessors. It is
+// embedded in every accessor so its methods are available on all of them.
+type options struct {
+	responseOptions
+
+	customHost          string
+	stripBasePath       string
+	autoBasePath        bool
+	contextHeaders      bool
+	maxBodySize         int64
+	maxBodySizeFunc     func(req *http.Request, limit int64) int64
+	bodyTooLargeType    string
+	bodyTooLargeBody    string
+	errorMapper         ErrorMapper
+	panicRecovery       PanicRecovery
+	decompressBody      bool
+	maxDecompressedSize int64
+	trustedProxies      []netip.Prefix
+	timeoutReserve      time.Duration
+	timeoutReserveSet   bool
+	neverSplitHeaders   map[string]bool
+}
+
+// WithCustomHost sets the scheme and host used to build request URLs, for
+// example https://api.example.com. It takes precedence over the GO_API_HOST
+// environment variable.
+func WithCustomHost(host string) Option {
+	return func(o *options) {
+		o.customHost = strings.TrimSuffix(host, "/")
+	}
+}
+
+// WithBasePath sets a base path that is removed from the request path before
+// the request is routed. See StripBasePath.
+func WithBasePath(basePath string) Option {
+	return func(o *options) {
+		o.StripBasePath(basePath)
+	}
+}
+
+// WithAutoBasePath enables automatic stage and base path handling. The stage
+// of execute-api URLs and the base path of custom domain mappings are
+// detected from the event and removed from the request path before routing.
+// Absolute paths in the Location and Content-Location response headers and in
+// the Path attribute of cookies are prefixed with them again. A base path set
+// with WithBasePath takes precedence over the detected one. Handlers can read
+// the detected prefix with GetBasePathFromContext.
+func WithAutoBasePath() Option {
+	return func(o *options) {
+		o.autoBasePath = true
+	}
+}
+
+// WithContextHeaders enables or disables the custom headers carrying the
+// event context and stage variables that ProxyEventToHTTPRequest adds to the
+// request. They are disabled by default: the context and stage variables are
+// stored in the request context instead, where the header accessors such as
+// GetAPIGatewayContext read them without marshalling. Enable the headers for
+// applications that read them directly.
+func WithContextHeaders(enabled bool) Option {
+	return func(o *options) {
+		o.contextHeaders = enabled
+	}
+}
+
+// WithBinaryContentTypes sets the media types of responses that are always
+// returned base64 encoded. Patterns can be exact media types such as
+// application/pdf, wildcard subtypes such as image/* or */*. It replaces the
+// media types of the binary policy.
+func WithBinaryContentTypes(patterns ...string) Option {
+	return func(o *options) {
+		o.binaryPolicy.MediaTypes = append([]string(nil), patterns...)
+	}
+}
+
+// WithBinaryPolicy sets the policy deciding which response bodies are
+// returned base64 encoded. See BinaryPolicy.
+func WithBinaryPolicy(policy BinaryPolicy) Option {
+	return func(o *options) {
+		policy.MediaTypes = append([]string(nil), policy.MediaTypes...)
+		o.binaryPolicy = policy
+	}
+}
+
+// WithContentTypeDetection enables or disables the detection of the
+// Content-Type of responses that do not set one with http.DetectContentType.
+// Detection is enabled by default. When it is disabled such responses have no
+// Content-Type, unless a default is set with WithDefaultContentType.
+func WithContentTypeDetection(enabled bool) Option {
+	return func(o *options) {
+		o.disableContentTypeDetection = !enabled
+	}
+}
+
+// WithDefaultContentType sets the Content-Type of responses that do not set
+// one, instead of detecting it.
+func WithDefaultContentType(contentType string) Option {
+	return func(o *options) {
+		o.defaultContentType = contentType
+	}
+}
+
+// WithCompression enables the compression of response bodies negotiated with
+// the Accept-Encoding header of the request. See Compression.
+func WithCompression(c Compression) Option {
+	return func(o *options) {
+		c.ContentTypes = append([]string(nil), c.ContentTypes...)
+		o.compression = &c
+	}
+}
+
+// WithResponseSizeLimit configures the handling of responses too large to be
+// returned by Lambda. By default responses larger than DefaultMaxResponseSize
+// are replaced with a 502 response. See ResponseSizeLimit.
+func WithResponseSizeLimit(l ResponseSizeLimit) Option {
+	return func(o *options) {
+		o.responseSizeLimit = &l
+	}
+}
+
+// WithMaxBodySize sets the maximum size, in bytes, of the decoded request
+// body. Larger requests are rejected with a RequestBodyTooLargeError during
+// event conversion and the adapters answer them with a 413 response. Zero,
+// the default, disables the limit.
+func WithMaxBodySize(size int64) Option {
+	return func(o *options) {
+		o.maxBodySize = size
+	}
+}
+
+// WithMaxBodySizeFunc sets a function that chooses the maximum body size of
+// each request, for example to allow larger bodies on upload endpoints. It is
+// called with the converted request, before its body is attached, and the
+// size set with WithMaxBodySize, and returns the size to enforce. A result of
+// zero or less disables the limit for the request.
+func WithMaxBodySizeFunc(fn func(req *http.Request, limit int64) int64) Option {
+	return func(o *options) {
+		o.maxBodySizeFunc = fn
+	}
+}
+
+// WithBodyTooLargeResponse sets the Content-Type and body of the 413 response
+// returned for requests exceeding the maximum body size. By default the
+// response is produced by the error mapper.
+func WithBodyTooLargeResponse(contentType, body string) Option {
+	return func(o *options) {
+		o.bodyTooLargeType = contentType
+		o.bodyTooLargeBody = body
+	}
+}
+
+// WithErrorMapper sets the ErrorMapper producing the responses to malformed
+// events, oversized bodies, invalid handler responses and other failures of
+// the adapters. By default ProblemDetailsMapper is used.
+func WithErrorMapper(mapper ErrorMapper) Option {
+	return func(o *options) {
+		o.errorMapper = mapper
+	}
+}
+
+// WithBodyDecompression enables transparent request body decompression. See
+// EnableBodyDecompression.
+func WithBodyDecompression(maxSize int64) Option {
+	return func(o *options) {
+		o.EnableBodyDecompression(maxSize)
+	}
+}
+
+// WithLogger sets the logger of the accessor and of its response writers.
+// Records carry the request ID of the Lambda invocation, the route key and
+// the event type as attributes where they are known. By default the default
+// slog logger is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithTrustedProxies sets the address ranges of proxies in front of the API,
+// such as a CloudFront distribution. When the address a request was received
+// from belongs to a trusted proxy, the X-Forwarded-For header is used to
+// determine the RemoteAddr of the request.
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(o *options) {
+		o.trustedProxies = append([]netip.Prefix(nil), prefixes...)
+	}
+}
+
+// WithTimeoutReserve sets the amount of time reserved before the Lambda
+// invocation deadline. See SetTimeoutReserve.
+func WithTimeoutReserve(reserve time.Duration) Option {
+	return func(o *options) {
+		o.SetTimeoutReserve(reserve)
+	}
+}
+
+// WithNeverSplitHeaders sets headers whose values are never split on commas.
+// See NeverSplitHeaders.
+func WithNeverSplitHeaders(headers ...string) Option {
+	return func(o *options) {
+		o.NeverSplitHeaders(headers...)
+	}
+}
+
+// ApplyOptions applies the given options to the accessor.
+func (o *options) ApplyOptions(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// StripBasePath instructs the RequestAccessor object that the given base
+// path should be removed from the request path before sending it to the
+// framework for routing. This is used when API Gateway is configured with
+// base path mappings in custom domain names.
+func (o *options) StripBasePath(basePath string) string {
+	if strings.Trim(basePath, " ") == "" {
+		o.stripBasePath = ""
+		return ""
+	}
+
+	newBasePath := basePath
+	if !strings.HasPrefix(newBasePath, "/") {
+		newBasePath = "/" + newBasePath
+	}
+
+	if strings.HasSuffix(newBasePath, "/") {
+		newBasePath = newBasePath[:len(newBasePath)-1]
+	}
+
+	o.stripBasePath = newBasePath
+
+	return newBasePath
+}
+
+// SetTimeoutReserve sets the amount of time reserved before the Lambda
+// invocation deadline when the adapters derive the request context. Handlers
+// see a context deadline that expires reserve before the function times out.
+// A zero value reserves no time: the request context expires with the
+// invocation, leaving no time to answer handlers that do not complete.
+// Negative values cannot extend the deadline past the invocation deadline and
+// are treated as zero. Until it is set DefaultTimeoutReserve is used.
+func (o *options) SetTimeoutReserve(reserve time.Duration) {
+	o.timeoutReserve = max(reserve, 0)
+	o.timeoutReserveSet = true
+}
+
+// TimeoutReserve returns the amount of time reserved before the Lambda
+// invocation deadline.
+func (o *options) TimeoutReserve() time.Duration {
+	if !o.timeoutReserveSet {
+		return DefaultTimeoutReserve
+	}
+	return o.timeoutReserve
+}
+
+// EnableBodyDecompression instructs the accessor to transparently decompress
+// request bodies sent with a gzip, deflate or br Content-Encoding.
+// The body is decompressed when the event is converted: the Content-Encoding
+// header is removed from the converted request, its ContentLength and
+// Content-Length header are set to the decompressed length, and bodies of more
+// than maxSize decompressed bytes, or of more than the maximum body size set
+// with WithMaxBodySize or WithMaxBodySizeFunc, fail the conversion with
+// ErrDecompressedBodyTooLarge and are answered with a 413 response. A maxSize
+// of zero or less uses DefaultMaxDecompressedBodySize.
+func (o *options) EnableBodyDecompression(maxSize int64) {
+	o.decompressBody = true
+	o.maxDecompressedSize = maxSize
+}
+
+// NeverSplitHeaders instructs the accessor to pass the values of the given
+// headers to the framework as a single value instead of splitting them on
+// commas. The headers are added to the default set of single-value headers.
+// Only API Gateway V2 events, which join repeated headers with commas, are
+// affected.
+func (o *options) NeverSplitHeaders(headers ...string) {
+	if o.neverSplitHeaders == nil {
+		o.neverSplitHeaders = make(map[string]bool)
+	}
+	for _, header := range headers {
+		o.neverSplitHeaders[textproto.CanonicalMIMEHeaderKey(header)] = true
+	}
+}
+
+// serverAddress returns the scheme and host used to build request URLs. The
+// custom host option takes precedence over the GO_API_HOST environment
+// variable, which takes precedence over the given domain name.
+func (o *options) serverAddress(domainName string) string {
+	if o.customHost != "" {
+		return o.customHost
+	}
+	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
+		return customAddress
+	}
+	return "https://" + domainName
+}
+
+// prepareBody checks the body of an event against the configured size limit
+// and attaches it to req. It returns the limit that applies to the request,
+// zero or less if there is none.
+func (o *options) prepareBody(req *http.Request, body *eventBody) (int64, error) {
+	limit := o.maxBodySize
+	if o.maxBodySizeFunc != nil {
+		limit = o.maxBodySizeFunc(req, limit)
+	}
+	if limit > 0 && body.contentLength > limit {
+		return limit, &RequestBodyTooLargeError{Size: body.contentLength, Limit: limit}
+	}
+	body.setRequestBody(req)
+	return limit, nil
+}
+
+// decompressedBodyLimit returns the maximum size of the decompressed body of
+// a request whose body size limit is bodyLimit: the smaller of that limit and
+// the decompression limit, so that compressed bodies cannot bypass the
+// maximum body size.
+func (o *options) decompressedBodyLimit(bodyLimit int64) int64 {
+	limit := o.maxDecompressedSize
+	if limit <= 0 {
+		limit = DefaultMaxDecompressedBodySize
+	}
+	if bodyLimit > 0 {
+		limit = min(limit, bodyLimit)
+	}
+	return limit
+}
+
+// remoteAddr returns the address of the client that sent the request. If the
+// request was received from a trusted proxy the X-Forwarded-For header is
+// walked from the right, skipping trusted proxies.
+func (o *options) remoteAddr(sourceIP string, header http.Header) string {
+	if len(o.trustedProxies) == 0 || (sourceIP != "" && !o.isTrustedProxy(sourceIP)) {
+		return sourceIP
+	}
+
+	addr := sourceIP
+	forwardedFor := header.Values("X-Forwarded-For")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		hops := strings.Split(forwardedFor[i], ",")
+		for j := len(hops) - 1; j >= 0; j-- {
+			hop := strings.TrimSpace(hops[j])
+			if hop == "" {
+				continue
+			}
+			addr = hop
+			if !o.isTrustedProxy(hop) {
+				return addr
+			}
+		}
+	}
+	return addr
+}
+
+func (o *options) isTrustedProxy(addr string) bool {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range o.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMediaType reports whether the media type of contentType matches one of
+// the patterns. Patterns can be exact media types, type/* or */*.
+func matchesMediaType(contentType string, patterns []string) bool {
+	if len(patterns) == 0 || contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*/*" || pattern == mediaType:
+			return true
+		case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, pattern[:len(pattern)-1]):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,406 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Accessor options tests", func() {
+	trustedProxies := core.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8"))
+
+	Context("Custom host", func() {
+		It("Uses the custom host option for every event type", func() {
+			opt := core.WithCustomHost("http://custom.example.com/")
+
+			v1Request := getProxyRequest("/orders", "GET")
+			v1Request.RequestContext = getRequestContext()
+			httpReq, err := core.NewRequestAccessor(opt).EventToRequest(v1Request)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.String()).To(Equal("http://custom.example.com/orders"))
+
+			v2Request := getProxyRequestV2("/orders", "GET")
+			v2Request.RequestContext.DomainName = "12abcdefgh.execute-api.us-east-2.amazonaws.com"
+			httpReq, err = core.NewRequestAccessorV2(opt).EventToRequest(v2Request)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.String()).To(Equal("http://custom.example.com/orders"))
+
+			albRequest := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false,
+				map[string]string{"host": "lambda-test-alb.us-east-1.elb.amazonaws.com"}, "", nil, nil, nil)
+			httpReq, err = core.NewRequestAccessorALB(opt).EventToRequest(albRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.String()).To(Equal("http://custom.example.com/orders"))
+		})
+
+		It("Takes precedence over the environment variable", func() {
+			os.Setenv(core.CustomHostVariable, "http://env.example.com")
+			defer os.Unsetenv(core.CustomHostVariable)
+
+			httpReq, err := core.NewRequestAccessor().EventToRequest(getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(httpReq.Host).To(Equal("env.example.com"))
+
+			httpReq, err = core.NewRequestAccessor(core.WithCustomHost("http://option.example.com")).EventToRequest(getProxyRequest("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(httpReq.Host).To(Equal("option.example.com"))
+		})
+
+		It("Honours the environment variable for ALB events", func() {
+			os.Setenv(core.CustomHostVariable, "http://env.example.com")
+			defer os.Unsetenv(core.CustomHostVariable)
+
+			albRequest := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false,
+				map[string]string{"host": "lambda-test-alb.us-east-1.elb.amazonaws.com"}, "", nil, nil, nil)
+			httpReq, err := core.NewRequestAccessorALB().EventToRequest(albRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Host).To(Equal("env.example.com"))
+		})
+
+		It("Reads the ALB host from multi-value headers", func() {
+			albRequest := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, nil, "", nil,
+				map[string][]string{"host": {"lambda-test-alb.us-east-1.elb.amazonaws.com"}}, nil)
+			httpReq, err := core.NewRequestAccessorALB().EventToRequest(albRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Host).To(Equal("lambda-test-alb.us-east-1.elb.amazonaws.com"))
+		})
+	})
+
+	Context("Base path", func() {
+		It("Strips the base path given as an option", func() {
+			accessor := core.NewRequestAccessorV2(core.WithBasePath("app1/"))
+			httpReq, err := accessor.EventToRequest(getProxyRequestV2("/app1/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(httpReq.URL.Path).To(Equal("/orders"))
+		})
+	})
+
+	Context("Context headers", func() {
+		It("Adds the context headers when enabled", func() {
+			opt := core.WithContextHeaders(true)
+
+			req := getProxyRequest("/orders", "GET")
+			req.RequestContext = getRequestContext()
+			httpReq, err := core.NewRequestAccessor(opt).ProxyEventToHTTPRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Get(core.APIGwContextHeader)).ToNot(BeEmpty())
+			Expect(httpReq.Header.Get(core.APIGwStageVarsHeader)).ToNot(BeEmpty())
+
+			httpReq, err = core.NewRequestAccessorV2(opt).ProxyEventToHTTPRequest(getProxyRequestV2("/orders", "GET"))
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Get(core.APIGwContextHeader)).ToNot(BeEmpty())
+
+			albRequest := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, nil, "", nil, nil, nil)
+			httpReq, err = core.NewRequestAccessorALB(opt).ProxyEventToHTTPRequest(albRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Get(core.ALBContextHeader)).ToNot(BeEmpty())
+		})
+
+		for _, opts := range [][]core.Option{nil, {core.WithContextHeaders(false)}} {
+			opts := opts
+			It("Omits the context headers by default or when disabled", func() {
+				req := getProxyRequest("/orders", "GET")
+				req.RequestContext = getRequestContext()
+				httpReq, err := core.NewRequestAccessor(opts...).ProxyEventToHTTPRequest(req)
+				Expect(err).To(BeNil())
+				Expect(httpReq.Header.Get(core.APIGwContextHeader)).To(BeEmpty())
+				Expect(httpReq.Header.Get(core.APIGwStageVarsHeader)).To(BeEmpty())
+
+				httpReq, err = core.NewRequestAccessorV2(opts...).ProxyEventToHTTPRequest(getProxyRequestV2("/orders", "GET"))
+				Expect(err).To(BeNil())
+				Expect(httpReq.Header.Get(core.APIGwContextHeader)).To(BeEmpty())
+
+				albRequest := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, nil, "", nil, nil, nil)
+				httpReq, err = core.NewRequestAccessorALB(opts...).ProxyEventToHTTPRequest(albRequest)
+				Expect(err).To(BeNil())
+				Expect(httpReq.Header.Get(core.ALBContextHeader)).To(BeEmpty())
+			})
+		}
+
+		It("Reads the context from the request context without headers", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.RequestContext = getRequestContext()
+			req.StageVariables = getStageVariables()
+			accessor := core.NewRequestAccessor()
+			httpReq, err := accessor.ProxyEventToHTTPRequest(req)
+			Expect(err).To(BeNil())
+			proxyContext, err := accessor.GetAPIGatewayContext(httpReq)
+			Expect(err).To(BeNil())
+			Expect(proxyContext.APIID).To(Equal("x"))
+			stageVars, err := accessor.GetAPIGatewayStageVars(httpReq)
+			Expect(err).To(BeNil())
+			Expect(stageVars["var1"]).To(Equal("value1"))
+
+			reqV2 := getProxyRequestV2("/orders", "GET")
+			reqV2.RequestContext = getRequestContextV2()
+			accessorV2 := core.NewRequestAccessorV2()
+			httpReq, err = accessorV2.ProxyEventToHTTPRequest(reqV2)
+			Expect(err).To(BeNil())
+			proxyContextV2, err := accessorV2.GetAPIGatewayContextV2(httpReq)
+			Expect(err).To(BeNil())
+			Expect(proxyContextV2.APIID).To(Equal(reqV2.RequestContext.APIID))
+
+			albRequest := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, nil, "", nil, nil, nil)
+			accessorALB := core.NewRequestAccessorALB()
+			httpReq, err = accessorALB.ProxyEventToHTTPRequest(albRequest)
+			Expect(err).To(BeNil())
+			albContext, err := accessorALB.GetContextALB(httpReq)
+			Expect(err).To(BeNil())
+			Expect(albContext.ELB.TargetGroupArn).To(Equal(albRequest.RequestContext.ELB.TargetGroupArn))
+		})
+
+		It("Removes context headers sent by the client when disabled", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.RequestContext = getRequestContext()
+			req.Headers = map[string]string{core.APIGwContextHeader: `{"accountId":"abc123"}`}
+			httpReq, err := core.NewRequestAccessor().ProxyEventToHTTPRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Get(core.APIGwContextHeader)).To(BeEmpty())
+		})
+	})
+
+	Context("Body size", func() {
+		It("Rejects bodies larger than the maximum size", func() {
+			opt := core.WithMaxBodySize(4)
+
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "hello"
+			_, err := core.NewRequestAccessor(opt).EventToRequest(req)
+			Expect(err).To(MatchError(core.ErrRequestBodyTooLarge))
+
+			v2Request := getProxyRequestV2("/orders", "POST")
+			v2Request.Body = "aGVsbG8="
+			v2Request.IsBase64Encoded = true
+			_, err = core.NewRequestAccessorV2(opt).EventToRequest(v2Request)
+			Expect(err).To(MatchError(core.ErrRequestBodyTooLarge))
+
+			albRequest := getALBProxyRequest("/orders", "POST", getALBRequestContext(), false, nil, "hello", nil, nil, nil)
+			_, err = core.NewRequestAccessorALB(opt).EventToRequest(albRequest)
+			Expect(err).To(MatchError(core.ErrRequestBodyTooLarge))
+		})
+
+		It("Reports the size and the limit", func() {
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "hello"
+			_, err := core.NewRequestAccessor(core.WithMaxBodySize(4)).EventToRequest(req)
+
+			var tooLarge *core.RequestBodyTooLargeError
+			Expect(errors.As(err, &tooLarge)).To(BeTrue())
+			Expect(tooLarge.Size).To(Equal(int64(5)))
+			Expect(tooLarge.Limit).To(Equal(int64(4)))
+		})
+
+		It("Lets routes override the maximum size", func() {
+			opts := []core.Option{
+				core.WithMaxBodySize(4),
+				core.WithMaxBodySizeFunc(func(req *http.Request, limit int64) int64 {
+					if req.Method == http.MethodPut && strings.HasPrefix(req.URL.Path, "/uploads/") {
+						return 0
+					}
+					if req.Header.Get("Content-Type") == "text/plain" {
+						return 2
+					}
+					return limit
+				}),
+			}
+			accessor := core.NewRequestAccessor(opts...)
+
+			req := getProxyRequest("/uploads/photo", "PUT")
+			req.Body = "a large photo"
+			httpReq, err := accessor.EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.ContentLength).To(Equal(int64(13)))
+
+			req = getProxyRequest("/orders", "POST")
+			req.Body = "abc"
+			req.Headers = map[string]string{"Content-Type": "text/plain"}
+			_, err = accessor.EventToRequest(req)
+			Expect(err).To(MatchError(core.ErrRequestBodyTooLarge))
+
+			req.Headers = nil
+			_, err = accessor.EventToRequest(req)
+			Expect(err).To(BeNil())
+		})
+
+		It("Answers bodies larger than the maximum size with a 413 response", func() {
+			err := &core.RequestBodyTooLargeError{Size: 5, Limit: 4}
+
+			resp, respErr := core.NewRequestAccessor().ErrorResponse(context.Background(), getProxyRequest("/orders", "POST"), err)
+			Expect(respErr).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+
+			opt := core.WithBodyTooLargeResponse("text/plain", "too large")
+			respV2, respErr := core.NewRequestAccessorV2(opt).ErrorResponse(context.Background(), getProxyRequestV2("/orders", "POST"), err)
+			Expect(respErr).To(BeNil())
+			Expect(respV2.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(respV2.Headers["Content-Type"]).To(Equal("text/plain"))
+			Expect(respV2.Body).To(Equal("too large"))
+
+			albRequest := getALBProxyRequest("/orders", "POST", getALBRequestContext(), false, nil, "", nil, nil, nil)
+			respALB, respErr := core.NewRequestAccessorALB(opt).ErrorResponse(context.Background(), albRequest, err)
+			Expect(respErr).To(BeNil())
+			Expect(respALB.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(respALB.StatusDescription).To(Equal("413 Request Entity Too Large"))
+			Expect(respALB.Body).To(Equal("too large"))
+		})
+
+		It("Accepts bodies within the maximum size", func() {
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "hello"
+			httpReq, err := core.NewRequestAccessor(core.WithMaxBodySize(5)).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.ContentLength).To(Equal(int64(5)))
+		})
+	})
+
+	Context("Logger", func() {
+		It("Logs conversion errors once with the configured logger", func() {
+			var buf bytes.Buffer
+			accessor := core.NewRequestAccessor(core.WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "abc"
+			req.IsBase64Encoded = true
+			_, err := accessor.ProxyEventToHTTPRequest(req)
+			Expect(err).ToNot(BeNil())
+			Expect(buf.String()).To(BeEmpty())
+
+			_, respErr := accessor.ErrorResponse(context.Background(), req, err)
+			Expect(respErr).To(BeNil())
+			Expect(strings.Count(buf.String(), "\n")).To(Equal(1))
+			Expect(buf.String()).To(ContainSubstring("level=WARN msg=\"Rejected invalid proxy event\" routeKey=\"POST /orders\" eventType=APIGatewayProxyRequest"))
+			Expect(buf.String()).To(ContainSubstring(err.Error()))
+		})
+
+		It("Attaches the event attributes to the request logger", func() {
+			var buf bytes.Buffer
+			accessor := core.NewRequestAccessorV2(core.WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+			req := getProxyRequestV2("/orders/42", "GET")
+			req.RouteKey = "GET /orders/{id}"
+			ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-1"})
+			httpReq, err := accessor.EventToRequestWithContext(ctx, req)
+			Expect(err).To(BeNil())
+
+			accessor.RequestLogger(httpReq).Info("Served")
+			Expect(buf.String()).To(ContainSubstring("msg=Served requestId=req-1 routeKey=\"GET /orders/{id}\" eventType=APIGatewayV2HTTPRequest"))
+		})
+	})
+
+	Context("Trusted proxies", func() {
+		It("Uses the source IP when no proxy is trusted", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.RequestContext.Identity.SourceIP = "10.0.0.1"
+			req.Headers = map[string]string{"X-Forwarded-For": "203.0.113.7"}
+			httpReq, err := core.NewRequestAccessor().EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.RemoteAddr).To(Equal("10.0.0.1"))
+		})
+
+		It("Ignores X-Forwarded-For from untrusted clients", func() {
+			req := getProxyRequest("/orders", "GET")
+			req.RequestContext.Identity.SourceIP = "198.51.100.1"
+			req.Headers = map[string]string{"X-Forwarded-For": "203.0.113.7"}
+			httpReq, err := core.NewRequestAccessor(trustedProxies).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.RemoteAddr).To(Equal("198.51.100.1"))
+		})
+
+		It("Walks X-Forwarded-For past trusted proxies", func() {
+			req := getProxyRequestV2("/orders", "GET")
+			req.RequestContext.HTTP.SourceIP = "10.0.0.1"
+			req.Headers = map[string]string{"X-Forwarded-For": "192.0.2.44, 203.0.113.7, 10.1.2.3"}
+			httpReq, err := core.NewRequestAccessorV2(trustedProxies).EventToRequest(req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.RemoteAddr).To(Equal("203.0.113.7"))
+		})
+
+		It("Uses the last X-Forwarded-For entry as the ALB source IP", func() {
+			albRequest := getALBProxyRequest("/orders", "GET", getALBRequestContext(), false, nil, "", nil,
+				map[string][]string{"X-Forwarded-For": {"192.0.2.44, 203.0.113.7"}}, nil)
+			httpReq, err := core.NewRequestAccessorALB().EventToRequest(albRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.RemoteAddr).To(Equal("203.0.113.7"))
+
+			albRequest.MultiValueHeaders["X-Forwarded-For"] = []string{"192.0.2.44, 203.0.113.7, 10.1.2.3"}
+			httpReq, err = core.NewRequestAccessorALB(trustedProxies).EventToRequest(albRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.RemoteAddr).To(Equal("203.0.113.7"))
+		})
+	})
+
+	Context("Binary content types", func() {
+		It("Base64 encodes matching responses", func() {
+			accessor := core.NewRequestAccessor(core.WithBinaryContentTypes("image/*", "application/pdf"))
+
+			for contentType, binary := range map[string]bool{
+				"image/svg+xml":                true,
+				"application/pdf":              true,
+				"application/json":             false,
+				"text/plain; charset=utf-8":    false,
+				"Application/PDF; version=1.7": true,
+			} {
+				w := accessor.NewProxyResponseWriter()
+				w.Header().Set("Content-Type", contentType)
+				w.Write([]byte("<svg/>"))
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				Expect(resp.IsBase64Encoded).To(Equal(binary), contentType)
+			}
+		})
+
+		It("Applies to every response writer", func() {
+			opt := core.WithBinaryContentTypes("*/*")
+
+			w2 := core.NewRequestAccessorV2(opt).NewProxyResponseWriterV2()
+			w2.Write([]byte("hello"))
+			resp2, err := w2.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp2.IsBase64Encoded).To(BeTrue())
+			Expect(resp2.Body).To(Equal("aGVsbG8="))
+
+			wALB := core.NewRequestAccessorALB(opt).NewProxyResponseWriterALB()
+			wALB.WriteHeader(http.StatusOK)
+			wALB.Write([]byte("hello"))
+			respALB, err := wALB.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(respALB.IsBase64Encoded).To(BeTrue())
+		})
+	})
+
+	Context("Timeout reserve", func() {
+		It("Configures the timeout reserve", func() {
+			Expect(core.NewRequestAccessor().TimeoutReserve()).To(Equal(core.DefaultTimeoutReserve))
+			Expect(core.NewRequestAccessorALB(core.WithTimeoutReserve(time.Second)).TimeoutReserve()).To(Equal(time.Second))
+		})
+
+		It("Reserves no time when the reserve is zero", func() {
+			accessor := core.NewRequestAccessorV2(core.WithTimeoutReserve(0))
+			Expect(accessor.TimeoutReserve()).To(Equal(time.Duration(0)))
+
+			deadline := time.Now().Add(10 * time.Second)
+			lambdaCtx, lambdaCancel := context.WithDeadline(context.Background(), deadline)
+			defer lambdaCancel()
+			ctx, cancel := core.NewInvocationContext(lambdaCtx, accessor.TimeoutReserve())
+			defer cancel()
+			reqDeadline, _ := ctx.Deadline()
+			Expect(reqDeadline).To(Equal(deadline))
+		})
+
+		It("Treats negative reserves as zero", func() {
+			Expect(core.NewRequestAccessor(core.WithTimeoutReserve(-time.Second)).TimeoutReserve()).To(Equal(time.Duration(0)))
+		})
+	})
+})
@@ -0,0 +1,259 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxResponseSize is the maximum size, in bytes, of the response
+// payload of a synchronous Lambda invocation.
+const DefaultMaxResponseSize = 6 * 1024 * 1024
+
+// responseTooLargeResponseBody is the body of the response returned in place
+// of responses exceeding the maximum size.
+const responseTooLargeResponseBody = `{"message":"Response payload too large"}`
+
+// ObjectStore stores the bodies of responses that are too large to be
+// returned by Lambda, such as an S3 bucket.
+type ObjectStore interface {
+	// Put stores body under key and returns a URL from which clients can
+	// download it, such as a presigned S3 URL. The header holds the headers of
+	// the original response, including its Content-Type.
+	Put(ctx context.Context, key string, header http.Header, body []byte) (string, error)
+}
+
+// ResponseSizeLimit configures how the response writers handle responses
+// whose payload exceeds the size Lambda can return.
+type ResponseSizeLimit struct {
+	// MaxSize is the maximum size, in bytes, of the response payload,
+	// including the base64 encoding of binary bodies, the JSON escaping of
+	// text bodies and an estimate of the headers. Zero uses
+	// DefaultMaxResponseSize.
+	MaxSize int
+
+	// StatusCode is the status of the response returned in place of an
+	// oversized response when there is no Store or the upload fails, such as
+	// http.StatusRequestEntityTooLarge. Zero uses http.StatusBadGateway.
+	StatusCode int
+
+	// Store receives the bodies of oversized responses. Clients are
+	// redirected to the URL it returns with a 303 See Other response.
+	Store ObjectStore
+
+	// MaxBufferSize is the maximum size, in bytes, of the bodies buffered
+	// while they may still fit once compressed or must be uploaded to the
+	// Store. Larger bodies are discarded and replaced with the error response.
+	// Zero uses DefaultMaxBufferFactor times MaxSize.
+	MaxBufferSize int
+}
+
+// DefaultMaxBufferFactor is the multiple of the maximum payload size up to
+// which bodies are buffered when ResponseSizeLimit.MaxBufferSize is not set.
+const DefaultMaxBufferFactor = 4
+
+func (l *ResponseSizeLimit) maxSize() int {
+	if l == nil || l.MaxSize <= 0 {
+		return DefaultMaxResponseSize
+	}
+	return l.MaxSize
+}
+
+func (l *ResponseSizeLimit) maxBufferSize() int {
+	if l == nil || l.MaxBufferSize <= 0 {
+		return DefaultMaxBufferFactor * l.maxSize()
+	}
+	return l.MaxBufferSize
+}
+
+// discardBody reports whether a body of the given size, whose last part
+// chunk is being written, can no longer be returned and may be discarded
+// while the handler writes it. The payload size is estimated from the
+// headers and the size of the body once encoded, accounting for the base64
+// encoding of the bodies the binary policy encodes. Bodies that may still
+// shrink through compression or must be uploaded are kept up to the maximum
+// buffer size.
+func (o responseOptions) discardBody(header http.Header, size int, chunk []byte) bool {
+	limit := o.responseSizeLimit
+	if o.compression != nil || (limit != nil && limit.Store != nil) {
+		return size > limit.maxBufferSize()
+	}
+
+	maxSize := limit.maxSize()
+	headerSize := responsePayloadSize(header, "")
+	if size+headerSize > maxSize {
+		return true
+	}
+	if base64.StdEncoding.EncodedLen(size)+headerSize <= maxSize {
+		return false
+	}
+	return o.binaryPolicy.isBinary(header, completeRunes(chunk))
+}
+
+// completeRunes returns chunk, a part of a body written in several chunks,
+// without the bytes of the runes split with the previous and next chunks,
+// so that its UTF-8 validity says whether the body is text.
+func completeRunes(chunk []byte) []byte {
+	for i := 1; i < utf8.UTFMax && len(chunk) > 0 && !utf8.RuneStart(chunk[0]); i++ {
+		chunk = chunk[1:]
+	}
+	for i := 1; i < utf8.UTFMax && i <= len(chunk); i++ {
+		if utf8.RuneStart(chunk[len(chunk)-i]) {
+			if !utf8.FullRune(chunk[len(chunk)-i:]) {
+				chunk = chunk[:len(chunk)-i]
+			}
+			break
+		}
+	}
+	return chunk
+}
+
+// responseTooLarge reports whether a response with the given headers and
+// encoded body exceeds the maximum payload size. Bodies that fit even if
+// every byte had to be escaped in the JSON payload are not scanned.
+func (o responseOptions) responseTooLarge(header http.Header, output string) bool {
+	maxSize := o.responseSizeLimit.maxSize()
+	if maxJSONEscapeLen*len(output)+responsePayloadSize(header, "") <= maxSize {
+		return false
+	}
+	return responsePayloadSize(header, output) > maxSize
+}
+
+// oversizedResponse returns the status, headers and body of the response
+// replacing a response that is too large. The body is uploaded to the object
+// store if one is configured and the client redirected to it, otherwise an
+// error response is returned. The original body may be nil if it was
+// discarded.
+func (o responseOptions) oversizedResponse(ctx context.Context, req string, header http.Header, body []byte) (int, http.Header, string) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := o.routeLogger(ctx, req)
+	limit := o.responseSizeLimit
+	if limit != nil && limit.Store != nil && body != nil {
+		key, err := newObjectKey()
+		if err == nil {
+			var location string
+			location, err = limit.Store.Put(ctx, key, header, body)
+			if err == nil {
+				logger.Info("Response exceeds the maximum payload size, uploaded it to the object store", "key", key)
+				redirect := make(http.Header)
+				redirect.Set("Location", location)
+				redirect.Set("Cache-Control", "no-store")
+				return http.StatusSeeOther, redirect, ""
+			}
+		}
+		logger.Error("Could not upload the oversized response", "error", err)
+	} else {
+		logger.Warn("Response exceeds the maximum payload size")
+	}
+
+	status := http.StatusBadGateway
+	if limit != nil && limit.StatusCode != 0 {
+		status = limit.StatusCode
+	}
+	errHeader := make(http.Header)
+	errHeader.Set(contentTypeHeaderKey, "application/json")
+	return status, errHeader, responseTooLargeResponseBody
+}
+
+// responsePayloadSize estimates the size of the serialized proxy response
+// from its headers and encoded body, as JSON strings.
+func responsePayloadSize(header http.Header, output string) int {
+	size := jsonStringLen(output)
+	for k, values := range header {
+		for _, v := range values {
+			size += jsonStringLen(k) + jsonStringLen(v) + 8
+		}
+	}
+	return size
+}
+
+// maxJSONEscapeLen is the length of the longest escape sequence of a byte in
+// a JSON string, such as \u001f.
+const maxJSONEscapeLen = 6
+
+// jsonStringLen returns the length of s once escaped in a JSON string by
+// encoding/json, without the quotes. The HTML characters escaped when the
+// Lambda runtime is configured with WithSetEscapeHTML are not counted.
+func jsonStringLen(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\' || c == '\n' || c == '\r' || c == '\t':
+				n += 2
+			case c < 0x20:
+				n += maxJSONEscapeLen
+			default:
+				n++
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// invalid bytes are replaced with \ufffd
+			n += maxJSONEscapeLen
+		case r == '\u2028' || r == '\u2029':
+			n += maxJSONEscapeLen
+		default:
+			n += size
+		}
+		i += size
+	}
+	return n
+}
+
+func newObjectKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// FileObjectStore is an ObjectStore that writes response bodies to a local
+// directory. It stands in for a real object store in tests and local
+// development, with a server publishing Dir at BaseURL.
+type FileObjectStore struct {
+	// Dir is the directory the bodies are written to.
+	Dir string
+	// BaseURL is the URL under which the files in Dir are served.
+	BaseURL string
+	// Expires is the validity of the returned URLs, mimicking presigned URLs.
+	// Zero uses 15 minutes.
+	Expires time.Duration
+}
+
+// Put writes body to a file named key in the store directory and returns its
+// URL.
+func (s *FileObjectStore) Put(ctx context.Context, key string, header http.Header, body []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(s.Dir, filepath.Base(key)), body, 0o600); err != nil {
+		return "", err
+	}
+
+	expires := s.Expires
+	if expires <= 0 {
+		expires = 15 * time.Minute
+	}
+	query := url.Values{}
+	query.Set("X-Expires", strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+	if contentType := header.Get(contentTypeHeaderKey); contentType != "" {
+		query.Set("response-content-type", contentType)
+	}
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + url.PathEscape(key) + "?" + query.Encode(), nil
+}
@@ -0,0 +1,161 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingStore struct{}
+
+func (failingStore) Put(ctx context.Context, key string, header http.Header, body []byte) (string, error) {
+	return "", errors.New("store unavailable")
+}
+
+var _ = Describe("Oversized response tests", func() {
+	Context("Without an object store", func() {
+		It("Replaces responses larger than the Lambda limit with a 502", func() {
+			body := []byte(strings.Repeat("a", core.DefaultMaxResponseSize+1))
+
+			w := core.NewRequestAccessor().NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write(body[:1024])
+			w.Write(body[1024:])
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/json"}))
+			Expect(resp.Body).To(Equal(`{"message":"Response payload too large"}`))
+
+			w2 := core.NewRequestAccessorV2().NewProxyResponseWriterV2()
+			w2.Write(body)
+			resp2, err := w2.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp2.StatusCode).To(Equal(http.StatusBadGateway))
+
+			wALB := core.NewRequestAccessorALB().NewProxyResponseWriterALB()
+			wALB.WriteHeader(http.StatusOK)
+			wALB.Write(body)
+			respALB, err := wALB.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(respALB.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(respALB.StatusDescription).To(Equal("502 Bad Gateway"))
+		})
+
+		It("Accounts for the JSON escaping of text bodies", func() {
+			opt := core.WithResponseSizeLimit(core.ResponseSizeLimit{MaxSize: 1000, StatusCode: http.StatusRequestEntityTooLarge})
+
+			w := core.NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`"` + strings.Repeat(`\"`, 300) + `"`))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+
+			w = core.NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`"` + strings.Repeat("a", 600) + `"`))
+			resp, err = w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("Accounts for the base64 encoding of binary bodies", func() {
+			opt := core.WithResponseSizeLimit(core.ResponseSizeLimit{MaxSize: 1000, StatusCode: http.StatusRequestEntityTooLarge})
+
+			w := core.NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("a", 900)))
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			w = core.NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(strings.Repeat("\xff", 900)))
+			resp, err = w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(resp.IsBase64Encoded).To(BeFalse())
+		})
+
+		It("Keeps responses that fit once compressed", func() {
+			w := core.NewRequestAccessor(
+				core.WithResponseSizeLimit(core.ResponseSizeLimit{MaxSize: 1000, MaxBufferSize: 10000}),
+				core.WithCompression(core.Compression{}),
+			).NewProxyResponseWriter()
+			req := httptest.NewRequest(http.MethodGet, "/report", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w.BindRequest(req, time.Second)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("a", 5000)))
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.MultiValueHeaders["Content-Encoding"]).To(Equal([]string{"gzip"}))
+		})
+	})
+
+	Context("With an object store", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "responses")
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("Uploads oversized responses and redirects to them", func() {
+			body := strings.Repeat("a", 2000)
+			store := &core.FileObjectStore{Dir: dir, BaseURL: "https://files.example.com/responses/"}
+
+			w := core.NewRequestAccessorV2(core.WithResponseSizeLimit(core.ResponseSizeLimit{MaxSize: 1000, Store: store})).NewProxyResponseWriterV2()
+			w.BindRequest(httptest.NewRequest(http.MethodGet, "/report", nil), time.Second)
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Add("Set-Cookie", "session=1")
+			w.Write([]byte(body))
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusSeeOther))
+			Expect(resp.Body).To(Equal(""))
+			Expect(resp.Cookies).To(BeEmpty())
+
+			location, err := url.Parse(resp.Headers["Location"])
+			Expect(err).To(BeNil())
+			Expect(location.Host).To(Equal("files.example.com"))
+			Expect(location.Query().Get("response-content-type")).To(Equal("text/csv"))
+			Expect(location.Query().Get("X-Expires")).ToNot(BeEmpty())
+
+			stored, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(location.Path, "/responses/")))
+			Expect(err).To(BeNil())
+			Expect(string(stored)).To(Equal(body))
+		})
+
+		It("Falls back to the error response when the upload fails", func() {
+			w := core.NewRequestAccessorALB(core.WithResponseSizeLimit(core.ResponseSizeLimit{MaxSize: 1000, Store: failingStore{}})).NewProxyResponseWriterALB()
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(strings.Repeat("a", 2000)))
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+		})
+	})
+})
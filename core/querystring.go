@@ -0,0 +1,88 @@
+package core
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// requestURL builds the URL of a request from the server address, the path
+// and the query string of an event in a single allocation. The query string
+// is omitted when empty.
+func requestURL(serverAddress, path, queryString string) string {
+	var b strings.Builder
+	n := len(serverAddress) + len(path)
+	if queryString != "" {
+		n += 1 + len(queryString)
+	}
+	b.Grow(n)
+	b.WriteString(serverAddress)
+	b.WriteString(path)
+	if queryString != "" {
+		b.WriteByte('?')
+		b.WriteString(queryString)
+	}
+	return b.String()
+}
+
+// buildQueryString builds the query string of a request from the query string
+// parameters of an event. Multi-value parameters take precedence over the
+// single-value ones, which are only used for backward compatibility.
+// Keys are sorted so that the result does not change between invocations,
+// values keep the order in which they appear in the event. escape is applied
+// to every key and value.
+func buildQueryString(multiValue map[string][]string, single map[string]string, escape func(string) string) string {
+	if len(multiValue) == 0 {
+		if len(single) == 0 {
+			return ""
+		}
+		multiValue = make(map[string][]string, len(single))
+		for key, value := range single {
+			multiValue[key] = []string{value}
+		}
+	}
+
+	keys := make([]string, 0, len(multiValue))
+	for key := range multiValue {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		escapedKey := escape(key)
+		for _, value := range multiValue[key] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(escapedKey)
+			b.WriteByte('=')
+			b.WriteString(escape(value))
+		}
+	}
+	return b.String()
+}
+
+// queryStringV1 builds the query string of an API Gateway REST API event.
+// API Gateway decodes the parameters, so keys and values are escaped again.
+func queryStringV1(multiValue map[string][]string, single map[string]string) string {
+	return buildQueryString(multiValue, single, url.QueryEscape)
+}
+
+// queryStringV2 builds the query string of an HTTP API event that does not
+// carry a raw query string. API Gateway joins the values of repeated
+// parameters with commas, so they are split back into separate parameters.
+func queryStringV2(single map[string]string) string {
+	multiValue := make(map[string][]string, len(single))
+	for key, value := range single {
+		multiValue[key] = strings.Split(value, ",")
+	}
+	return buildQueryString(multiValue, nil, url.QueryEscape)
+}
+
+// queryStringALB builds the query string of an ALB event. The load balancer
+// forwards keys and values exactly as the client sent them, still URL-encoded,
+// so they are used without escaping them a second time.
+func queryStringALB(multiValue map[string][]string, single map[string]string) string {
+	return buildQueryString(multiValue, single, func(s string) string { return s })
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is passed to the error mapper when the handler panics. The
+// adapters answer it with a 500 Internal Server Error response.
+type PanicError struct {
+	// Value is the value the handler panicked with.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+	// Route is the method and path of the request, such as "GET /orders".
+	Route string
+}
+
+func newPanicError(req *http.Request, value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+		Route: req.Method + " " + req.URL.Path,
+	}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic serving %s: %v", e.Route, e.Value)
+}
+
+// Unwrap returns the value of the panic if it is an error, such as
+// http.ErrAbortHandler.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// PanicRecovery configures how the adapters handle panics of the handler. See
+// WithPanicRecovery.
+type PanicRecovery struct {
+	// RepanicOnAbort makes the adapters panic again when the handler panics
+	// with http.ErrAbortHandler, failing the invocation as net/http aborts the
+	// response. By default a 500 response is returned.
+	RepanicOnAbort bool
+	// Report, if set, is called with every panic recovered from the handler,
+	// for example to send it to an error tracker. ctx is the request context.
+	Report func(ctx context.Context, err *PanicError)
+}
+
+// WithPanicRecovery configures how the adapters handle panics of the handler.
+// Panics are always recovered, logged with their stack, the route and the
+// Lambda request ID, and answered with a 500 response in the format of the
+// event; recovery keeps the execution environment of the function warm.
+func WithPanicRecovery(recovery PanicRecovery) Option {
+	return func(o *options) {
+		o.panicRecovery = recovery
+	}
+}
+
+// Serve serves req with handler like ServeWithTimeout, recovering the panics
+// of the handler. A recovered panic is logged and reported as configured with
+// WithPanicRecovery and returned as a *PanicError, and a handler that does not
+// complete before the deadline of the request is abandoned and reported with
+// a *TimeoutError; the adapters answer both with ErrorResponse. As in
+// net/http, panics with http.ErrAbortHandler are not logged nor reported.
+func (o *options) Serve(handler http.Handler, w http.ResponseWriter, req *http.Request) error {
+	logger := o.contextLogger(req.Context())
+	err := serveWithTimeout(logger, handler, w, req)
+	var p *PanicError
+	if !errors.As(err, &p) {
+		return err
+	}
+
+	if errors.Is(p, http.ErrAbortHandler) {
+		if o.panicRecovery.RepanicOnAbort {
+			panic(p.Value)
+		}
+		return p
+	}
+
+	o.routeLogger(req.Context(), p.Route).Error("Recovered panic", "panic", fmt.Sprint(p.Value), "stack", string(p.Stack))
+	if o.panicRecovery.Report != nil {
+		o.panicRecovery.Report(req.Context(), p)
+	}
+	return p
+}
@@ -0,0 +1,94 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Panic recovery tests", func() {
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	request := func(timeout time.Duration) (*http.Request, context.CancelFunc) {
+		ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-1"})
+		cancel := func() {}
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		return httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(ctx), cancel
+	}
+
+	for name, timeout := range map[string]time.Duration{"without a deadline": 0, "with a deadline": time.Minute} {
+		timeout := timeout
+
+		It("Recovers and reports panics "+name, func() {
+			var logs bytes.Buffer
+			var reported *core.PanicError
+			accessor := core.NewRequestAccessor(
+				core.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+				core.WithPanicRecovery(core.PanicRecovery{
+					Report: func(ctx context.Context, err *core.PanicError) {
+						reported = err
+					},
+				}),
+			)
+			req, cancel := request(timeout)
+			defer cancel()
+
+			w := accessor.NewProxyResponseWriter()
+			err := accessor.Serve(panicking, w, w.BindRequest(req, 0))
+			var panicErr *core.PanicError
+			Expect(errors.As(err, &panicErr)).To(BeTrue())
+			Expect(panicErr.Value).To(Equal("boom"))
+			Expect(panicErr.Route).To(Equal("GET /orders"))
+			Expect(string(panicErr.Stack)).To(ContainSubstring("recover_test.go"))
+			Expect(reported).To(Equal(panicErr))
+			Expect(logs.String()).To(ContainSubstring(`msg="Recovered panic" requestId=req-1 routeKey="GET /orders" panic=boom`))
+
+			resp, respErr := accessor.ErrorResponse(req.Context(), getProxyRequest("/orders", "GET"), err)
+			Expect(respErr).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+		})
+	}
+
+	It("Does not log aborted handlers", func() {
+		var logs bytes.Buffer
+		accessor := core.NewRequestAccessorV2(core.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+		req, cancel := request(time.Minute)
+		defer cancel()
+
+		w := accessor.NewProxyResponseWriterV2()
+		err := accessor.Serve(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic(http.ErrAbortHandler)
+		}), w, w.BindRequest(req, 0))
+		Expect(errors.Is(err, http.ErrAbortHandler)).To(BeTrue())
+		Expect(logs.String()).To(BeEmpty())
+	})
+
+	It("Panics again for aborted handlers when configured", func() {
+		accessor := core.NewRequestAccessorALB(core.WithPanicRecovery(core.PanicRecovery{RepanicOnAbort: true}))
+		req, cancel := request(0)
+		defer cancel()
+
+		w := accessor.NewProxyResponseWriterALB()
+		Expect(func() {
+			accessor.Serve(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				panic(http.ErrAbortHandler)
+			}), w, w.BindRequest(req, 0))
+		}).To(PanicWith(http.ErrAbortHandler))
+	})
+})
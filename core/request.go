@@ -3,16 +3,10 @@
 package core
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/url"
-	"os"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -40,79 +34,91 @@ const (
 // RequestAccessor objects give access to custom API Gateway properties
 // in the request.
 type RequestAccessor struct {
-	stripBasePath string
+	options
 }
 
-// GetAPIGatewayContext extracts the API Gateway context object from a
-// request's custom header.
+// NewRequestAccessor returns a RequestAccessor configured with the given
+// options.
+func NewRequestAccessor(opts ...Option) *RequestAccessor {
+	r := &RequestAccessor{}
+	r.ApplyOptions(opts...)
+	return r
+}
+
+// NewProxyResponseWriter returns a new ProxyResponseWriter object that honours the
+// response options of the accessor, such as the binary content types.
+func (r *RequestAccessor) NewProxyResponseWriter() *ProxyResponseWriter {
+	w := NewProxyResponseWriter()
+	w.responseOptions = r.responseOptions
+	return w
+}
+
+// GetAPIGatewayContext extracts the API Gateway context object from the
+// request context, or from a request's custom header when the request was
+// not converted by this package.
 // Returns a populated events.APIGatewayProxyRequestContext object from
 // the request.
 func (r *RequestAccessor) GetAPIGatewayContext(req *http.Request) (events.APIGatewayProxyRequestContext, error) {
+	if context, ok := GetAPIGatewayContextFromContext(req.Context()); ok {
+		return context, nil
+	}
 	if req.Header.Get(APIGwContextHeader) == "" {
-		return events.APIGatewayProxyRequestContext{}, errors.New("No context header in request")
+		return events.APIGatewayProxyRequestContext{}, ErrNoContextHeader
 	}
 	context := events.APIGatewayProxyRequestContext{}
 	err := json.Unmarshal([]byte(req.Header.Get(APIGwContextHeader)), &context)
 	if err != nil {
-		log.Println("Error while unmarshalling context")
-		log.Println(err)
-		return events.APIGatewayProxyRequestContext{}, err
+		r.contextLogger(req.Context()).Error("Error while unmarshalling context", "error", err)
+		return events.APIGatewayProxyRequestContext{}, &DecodingError{Name: APIGwContextHeader + " header", Err: err}
 	}
 	return context, nil
 }
 
-// GetAPIGatewayStageVars extracts the API Gateway stage variables from a
-// request's custom header.
+// GetAPIGatewayStageVars extracts the API Gateway stage variables from the
+// request context, or from a request's custom header when the request was
+// not converted by this package.
 // Returns a map[string]string of the stage variables and their values from
 // the request.
 func (r *RequestAccessor) GetAPIGatewayStageVars(req *http.Request) (map[string]string, error) {
+	if stageVars, ok := GetStageVarsFromContext(req.Context()); ok {
+		if stageVars == nil {
+			stageVars = make(map[string]string)
+		}
+		return stageVars, nil
+	}
 	stageVars := make(map[string]string)
 	if req.Header.Get(APIGwStageVarsHeader) == "" {
-		return stageVars, errors.New("No stage vars header in request")
+		return stageVars, ErrNoStageVarsHeader
 	}
 	err := json.Unmarshal([]byte(req.Header.Get(APIGwStageVarsHeader)), &stageVars)
 	if err != nil {
-		log.Println("Error while unmarshalling stage variables")
-		log.Println(err)
-		return stageVars, err
+		r.contextLogger(req.Context()).Error("Error while unmarshalling stage variables", "error", err)
+		return stageVars, &DecodingError{Name: APIGwStageVarsHeader + " header", Err: err}
 	}
 	return stageVars, nil
 }
 
-// StripBasePath instructs the RequestAccessor object that the given base
-// path should be removed from the request path before sending it to the
-// framework for routing. This is used when API Gateway is configured with
-// base path mappings in custom domain names.
-func (r *RequestAccessor) StripBasePath(basePath string) string {
-	if strings.Trim(basePath, " ") == "" {
-		r.stripBasePath = ""
-		return ""
-	}
-
-	newBasePath := basePath
-	if !strings.HasPrefix(newBasePath, "/") {
-		newBasePath = "/" + newBasePath
-	}
-
-	if strings.HasSuffix(newBasePath, "/") {
-		newBasePath = newBasePath[:len(newBasePath)-1]
-	}
-
-	r.stripBasePath = newBasePath
-
-	return newBasePath
-}
-
 // ProxyEventToHTTPRequest converts an API Gateway proxy event into a http.Request object.
-// Returns the populated http request with additional two custom headers for the stage variables and API Gateway context.
+// Returns the populated http request with the stage variables and API Gateway context as part of its context,
+// and in two custom headers when they are enabled with WithContextHeaders.
 // To access these properties use the GetAPIGatewayStageVars and GetAPIGatewayContext method of the RequestAccessor object.
 func (r *RequestAccessor) ProxyEventToHTTPRequest(req events.APIGatewayProxyRequest) (*http.Request, error) {
 	httpRequest, err := r.EventToRequest(req)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
-	return addToHeader(httpRequest, req)
+	httpRequest = addToContext(httpRequest.Context(), httpRequest, req)
+	if !r.contextHeaders {
+		// headers sent by the client must not be mistaken for ours
+		httpRequest.Header.Del(APIGwStageVarsHeader)
+		httpRequest.Header.Del(APIGwContextHeader)
+		return httpRequest, nil
+	}
+	httpRequest, err = addToHeader(httpRequest, req, r.contextLogger(httpRequest.Context()))
+	if err != nil {
+		return nil, &ConversionError{Err: err}
+	}
+	return httpRequest, nil
 }
 
 // EventToRequestWithContext converts an API Gateway proxy event and context into an http.Request object.
@@ -121,77 +127,51 @@ func (r *RequestAccessor) ProxyEventToHTTPRequest(req events.APIGatewayProxyRequ
 func (r *RequestAccessor) EventToRequestWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (*http.Request, error) {
 	httpRequest, err := r.EventToRequest(req)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
-	return addToContext(ctx, httpRequest, req), nil
+	return copyContextValues(httpRequest, addToContext(ctx, httpRequest, req)), nil
 }
 
 // EventToRequest converts an API Gateway proxy event into an http.Request object.
-// Returns the populated request maintaining headers
+// Returns the populated request maintaining headers. Failures are returned as
+// a ConversionError wrapping their cause.
 func (r *RequestAccessor) EventToRequest(req events.APIGatewayProxyRequest) (*http.Request, error) {
-	decodedBody := []byte(req.Body)
-	if req.IsBase64Encoded {
-		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
-		if err != nil {
-			return nil, err
-		}
-		decodedBody = base64Body
+	httpRequest, err := r.eventToRequest(req)
+	if err != nil {
+		return nil, &ConversionError{Err: err}
 	}
+	return httpRequest, nil
+}
 
-	path := req.Path
-	if r.stripBasePath != "" && len(r.stripBasePath) > 1 {
-		if strings.HasPrefix(path, r.stripBasePath) {
-			path = strings.Replace(path, r.stripBasePath, "", 1)
-		}
-	}
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
+func (r *RequestAccessor) eventToRequest(req events.APIGatewayProxyRequest) (*http.Request, error) {
+	if err := validateMethod("httpMethod", req.HTTPMethod); err != nil {
+		return nil, err
 	}
-	serverAddress := "https://" + req.RequestContext.DomainName
-	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
-		serverAddress = customAddress
+	body, err := newEventBody(req.Body, req.IsBase64Encoded)
+	if err != nil {
+		return nil, &InvalidEventError{Field: "body", Err: &DecodingError{Name: "base64 body", Err: err}}
 	}
-	path = serverAddress + path
-
-	if len(req.MultiValueQueryStringParameters) > 0 {
-		queryString := ""
-		for q, l := range req.MultiValueQueryStringParameters {
-			for _, v := range l {
-				if queryString != "" {
-					queryString += "&"
-				}
-				queryString += url.QueryEscape(q) + "=" + url.QueryEscape(v)
-			}
-		}
-		path += "?" + queryString
-	} else if len(req.QueryStringParameters) > 0 {
-		// Support `QueryStringParameters` for backward compatibility.
-		// https://github.com/awslabs/aws-lambda-go-api-proxy/issues/37
-		queryString := ""
-		for q := range req.QueryStringParameters {
-			if queryString != "" {
-				queryString += "&"
-			}
-			queryString += url.QueryEscape(q) + "=" + url.QueryEscape(req.QueryStringParameters[q])
-		}
-		path += "?" + queryString
+
+	strip, basePath := r.stripBasePath, ""
+	if r.autoBasePath {
+		strip, basePath = r.basePathV1(req)
 	}
+	serverAddress := r.serverAddress(req.RequestContext.DomainName)
+	// Support `QueryStringParameters` for backward compatibility.
+	// https://github.com/awslabs/aws-lambda-go-api-proxy/issues/37
+	queryString := queryStringV1(req.MultiValueQueryStringParameters, req.QueryStringParameters)
+	path := requestURL(serverAddress, trimBasePath(req.Path, strip), queryString)
 
 	httpRequest, err := http.NewRequest(
 		strings.ToUpper(req.HTTPMethod),
 		path,
-		bytes.NewReader(decodedBody),
+		nil,
 	)
 
 	if err != nil {
-		fmt.Printf("Could not convert request %s:%s to http.Request\n", req.HTTPMethod, req.Path)
-		log.Println(err)
-		return nil, err
+		return nil, newRequestError(serverAddress, err)
 	}
 
-	httpRequest.RemoteAddr = req.RequestContext.Identity.SourceIP
-
 	if req.MultiValueHeaders != nil {
 		for k, values := range req.MultiValueHeaders {
 			for _, value := range values {
@@ -204,21 +184,45 @@ func (r *RequestAccessor) EventToRequest(req events.APIGatewayProxyRequest) (*ht
 		}
 	}
 
+	bodyLimit, err := r.prepareBody(httpRequest, body)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizeRequest(httpRequest, req.RequestContext.Protocol)
+
+	httpRequest.RemoteAddr = r.remoteAddr(req.RequestContext.Identity.SourceIP, httpRequest.Header)
+
+	if r.decompressBody {
+		if err := decompressRequestBody(httpRequest, r.decompressedBodyLimit(bodyLimit)); err != nil {
+			return nil, err
+		}
+	}
+
 	httpRequest.RequestURI = httpRequest.URL.RequestURI()
 
-	return httpRequest, nil
+	return withLogInfo(withBasePath(httpRequest, basePath), eventTypeAPIGateway, routeKeyV1(req)), nil
+}
+
+// routeKeyV1 returns the route key of a REST API event in log records: its
+// method and resource, or its path when it has no resource.
+func routeKeyV1(req events.APIGatewayProxyRequest) string {
+	if req.Resource == "" {
+		return req.HTTPMethod + " " + req.Path
+	}
+	return req.HTTPMethod + " " + req.Resource
 }
 
-func addToHeader(req *http.Request, apiGwRequest events.APIGatewayProxyRequest) (*http.Request, error) {
+func addToHeader(req *http.Request, apiGwRequest events.APIGatewayProxyRequest, logger *slog.Logger) (*http.Request, error) {
 	stageVars, err := json.Marshal(apiGwRequest.StageVariables)
 	if err != nil {
-		log.Println("Could not marshal stage variables for custom header")
+		logger.Error("Could not marshal stage variables for custom header", "error", err)
 		return nil, err
 	}
 	req.Header.Set(APIGwStageVarsHeader, string(stageVars))
 	apiGwContext, err := json.Marshal(apiGwRequest.RequestContext)
 	if err != nil {
-		log.Println("Could not Marshal API GW context for custom header")
+		logger.Error("Could not marshal API GW context for custom header", "error", err)
 		return req, err
 	}
 	req.Header.Set(APIGwContextHeader, string(apiGwContext))
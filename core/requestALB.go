@@ -3,15 +3,10 @@
 package core
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -28,59 +23,65 @@ const (
 // RequestAccessorALB objects give access to custom ALB Target Group properties
 // in the request.
 type RequestAccessorALB struct {
-	stripBasePath string
+	options
 }
 
-// GetALBContext extracts the ALB context object from a request's custom header.
+// NewRequestAccessorALB returns a RequestAccessorALB configured with the given
+// options.
+func NewRequestAccessorALB(opts ...Option) *RequestAccessorALB {
+	r := &RequestAccessorALB{}
+	r.ApplyOptions(opts...)
+	return r
+}
+
+// NewProxyResponseWriterALB returns a new ProxyResponseWriterALB object that honours the
+// response options of the accessor, such as the binary content types.
+func (r *RequestAccessorALB) NewProxyResponseWriterALB() *ProxyResponseWriterALB {
+	w := NewProxyResponseWriterALB()
+	w.responseOptions = r.responseOptions
+	return w
+}
+
+// GetALBContext extracts the ALB context object from the request context, or
+// from a request's custom header when the request was not converted by this
+// package.
 // Returns a populated events.ALBTargetGroupRequestContext object from the request.
 func (r *RequestAccessorALB) GetContextALB(req *http.Request) (events.ALBTargetGroupRequestContext, error) {
+	if context, ok := GetTargetGroupRequetFromContextALB(req.Context()); ok {
+		return context, nil
+	}
 	if req.Header.Get(ALBContextHeader) == "" {
-		return events.ALBTargetGroupRequestContext{}, errors.New("no context header in request")
+		return events.ALBTargetGroupRequestContext{}, ErrNoContextHeader
 	}
 	context := events.ALBTargetGroupRequestContext{}
 	err := json.Unmarshal([]byte(req.Header.Get(ALBContextHeader)), &context)
 	if err != nil {
-		log.Println("Error while unmarshalling context")
-		log.Println(err)
-		return events.ALBTargetGroupRequestContext{}, err
+		r.contextLogger(req.Context()).Error("Error while unmarshalling context", "error", err)
+		return events.ALBTargetGroupRequestContext{}, &DecodingError{Name: ALBContextHeader + " header", Err: err}
 	}
 	return context, nil
 }
 
-// StripBasePath instructs the RequestAccessor object that the given base
-// path should be removed from the request path before sending it to the
-// framework for routing. This is used when API Gateway is configured with
-// base path mappings in custom domain names.
-func (r *RequestAccessorALB) StripBasePath(basePath string) string {
-	if strings.Trim(basePath, " ") == "" {
-		r.stripBasePath = ""
-		return ""
-	}
-
-	newBasePath := basePath
-	if !strings.HasPrefix(newBasePath, "/") {
-		newBasePath = "/" + newBasePath
-	}
-
-	if strings.HasSuffix(newBasePath, "/") {
-		newBasePath = newBasePath[:len(newBasePath)-1]
-	}
-
-	r.stripBasePath = newBasePath
-
-	return newBasePath
-}
-
 // ProxyEventToHTTPRequest converts an ALB Target Group Request event into a http.Request object.
-// Returns the populated http request with additional custom header for the ALB context.
+// Returns the populated http request with the ALB context as part of its context, and in a custom header
+// when it is enabled with WithContextHeaders.
 // To access these properties use the GetALBContext method of the RequestAccessorALB object.
 func (r *RequestAccessorALB) ProxyEventToHTTPRequest(req events.ALBTargetGroupRequest) (*http.Request, error) {
 	httpRequest, err := r.EventToRequest(req)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
-	return addToHeaderALB(httpRequest, req)
+	httpRequest = addToContextALB(httpRequest.Context(), httpRequest, req)
+	if !r.contextHeaders {
+		// headers sent by the client must not be mistaken for ours
+		httpRequest.Header.Del(ALBContextHeader)
+		return httpRequest, nil
+	}
+	httpRequest, err = addToHeaderALB(httpRequest, req, r.contextLogger(httpRequest.Context()))
+	if err != nil {
+		return nil, &ConversionError{Err: err}
+	}
+	return httpRequest, nil
 }
 
 // EventToRequestWithContext converts an ALB Target Group Request event and context into an http.Request object.
@@ -88,73 +89,49 @@ func (r *RequestAccessorALB) ProxyEventToHTTPRequest(req events.ALBTargetGroupRe
 func (r *RequestAccessorALB) EventToRequestWithContext(ctx context.Context, req events.ALBTargetGroupRequest) (*http.Request, error) {
 	httpRequest, err := r.EventToRequest(req)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
-	return addToContextALB(ctx, httpRequest, req), nil
+	return copyContextValues(httpRequest, withMultiValueHeadersALB(addToContextALB(ctx, httpRequest, req), isMultiValueALB(req))), nil
 }
 
 // EventToRequest converts an ALB TargetGroup event into an http.Request object.
-// Returns the populated request maintaining headers
+// Returns the populated request maintaining headers. Failures are returned as
+// a ConversionError wrapping their cause.
 func (r *RequestAccessorALB) EventToRequest(req events.ALBTargetGroupRequest) (*http.Request, error) {
-	decodedBody := []byte(req.Body)
-	if req.IsBase64Encoded {
-		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
-		if err != nil {
-			return nil, err
-		}
-		decodedBody = base64Body
+	httpRequest, err := r.eventToRequest(req)
+	if err != nil {
+		return nil, &ConversionError{Err: err}
 	}
+	return httpRequest, nil
+}
 
-	path := req.Path
-	if r.stripBasePath != "" && len(r.stripBasePath) > 1 {
-		if strings.HasPrefix(path, r.stripBasePath) {
-			path = strings.Replace(path, r.stripBasePath, "", 1)
-		}
+func (r *RequestAccessorALB) eventToRequest(req events.ALBTargetGroupRequest) (*http.Request, error) {
+	if err := validateMethod("httpMethod", req.HTTPMethod); err != nil {
+		return nil, err
 	}
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
-	}
-	serverAddress := "https://" + req.Headers["host"]
-	//  if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
-	//  	serverAddress = customAddress
-	//  }
-	path = serverAddress + path
-
-	if len(req.MultiValueQueryStringParameters) > 0 {
-		queryString := ""
-		for q, l := range req.MultiValueQueryStringParameters {
-			for _, v := range l {
-				if queryString != "" {
-					queryString += "&"
-				}
-				queryString += url.QueryEscape(q) + "=" + url.QueryEscape(v)
-			}
-		}
-		path += "?" + queryString
-	} else if len(req.QueryStringParameters) > 0 {
-		// Support `QueryStringParameters` for backward compatibility.
-		// https://github.com/awslabs/aws-lambda-go-api-proxy/issues/37
-		queryString := ""
-		for q := range req.QueryStringParameters {
-			if queryString != "" {
-				queryString += "&"
-			}
-			queryString += url.QueryEscape(q) + "=" + url.QueryEscape(req.QueryStringParameters[q])
-		}
-		path += "?" + queryString
+	body, err := newEventBody(req.Body, req.IsBase64Encoded)
+	if err != nil {
+		return nil, &InvalidEventError{Field: "body", Err: &DecodingError{Name: "base64 body", Err: err}}
 	}
 
+	strip, basePath := r.stripBasePath, ""
+	if r.autoBasePath {
+		strip, basePath = r.basePathALB()
+	}
+	serverAddress := r.serverAddress(HostALB(req))
+	// Support `QueryStringParameters` for backward compatibility.
+	// https://github.com/awslabs/aws-lambda-go-api-proxy/issues/37
+	queryString := queryStringALB(req.MultiValueQueryStringParameters, req.QueryStringParameters)
+	path := requestURL(serverAddress, trimBasePath(req.Path, strip), queryString)
+
 	httpRequest, err := http.NewRequest(
 		strings.ToUpper(req.HTTPMethod),
 		path,
-		bytes.NewReader(decodedBody),
+		nil,
 	)
 
 	if err != nil {
-		fmt.Printf("Could not convert request %s:%s to http.Request\n", req.HTTPMethod, req.Path)
-		log.Println(err)
-		return nil, err
+		return nil, newRequestError(serverAddress, err)
 	}
 
 	if req.MultiValueHeaders != nil {
@@ -169,21 +146,89 @@ func (r *RequestAccessorALB) EventToRequest(req events.ALBTargetGroupRequest) (*
 		}
 	}
 
+	bodyLimit, err := r.prepareBody(httpRequest, body)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizeRequest(httpRequest, "")
+
+	httpRequest.RemoteAddr = r.remoteAddr(albSourceIP(httpRequest.Header), httpRequest.Header)
+
+	if r.decompressBody {
+		if err := decompressRequestBody(httpRequest, r.decompressedBodyLimit(bodyLimit)); err != nil {
+			return nil, err
+		}
+	}
+
 	httpRequest.RequestURI = httpRequest.URL.RequestURI()
 
-	return httpRequest, nil
+	httpRequest = withMultiValueHeadersALB(httpRequest, isMultiValueALB(req))
+	return withLogInfo(withBasePath(httpRequest, basePath), eventTypeALB, routeKeyALB(req)), nil
 }
 
-func addToHeaderALB(req *http.Request, albRequest events.ALBTargetGroupRequest) (*http.Request, error) {
+type multiValueHeadersKey struct{}
+
+// isMultiValueALB reports whether the target group that sent an event has
+// multi-value headers enabled. Such events carry their headers and query
+// string parameters in the multi-value fields only.
+func isMultiValueALB(req events.ALBTargetGroupRequest) bool {
+	return req.MultiValueHeaders != nil || req.MultiValueQueryStringParameters != nil
+}
+
+// withMultiValueHeadersALB records in the context of req whether the response
+// must use multi-value headers, so that ProxyResponseWriterALB answers in the
+// format expected by the target group.
+func withMultiValueHeadersALB(req *http.Request, multiValue bool) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), multiValueHeadersKey{}, multiValue))
+}
+
+// routeKeyALB returns the route key of an ALB event in log records. Target
+// groups have no routes, so it is the method and path of the request.
+func routeKeyALB(req events.ALBTargetGroupRequest) string {
+	return req.HTTPMethod + " " + req.Path
+}
+
+func addToHeaderALB(req *http.Request, albRequest events.ALBTargetGroupRequest, logger *slog.Logger) (*http.Request, error) {
 	albContext, err := json.Marshal(albRequest.RequestContext)
 	if err != nil {
-		log.Println("Could not Marshal ALB context for custom header")
+		logger.Error("Could not marshal ALB context for custom header", "error", err)
 		return req, err
 	}
 	req.Header.Set(ALBContextHeader, string(albContext))
 	return req, nil
 }
 
+// HostALB returns the value of the Host header of an ALB event, which is
+// found in either the single or the multi-value headers depending on the
+// target group configuration. ALB events have no domain name in their request
+// context, so it is the host the request was sent to.
+func HostALB(req events.ALBTargetGroupRequest) string {
+	for k, v := range req.Headers {
+		if strings.EqualFold(k, "host") {
+			return v
+		}
+	}
+	for k, values := range req.MultiValueHeaders {
+		if strings.EqualFold(k, "host") && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// albSourceIP returns the address the load balancer received the request
+// from. ALB events carry no source IP, the load balancer appends it to the
+// X-Forwarded-For header instead.
+func albSourceIP(header http.Header) string {
+	values := header.Values("X-Forwarded-For")
+	if len(values) == 0 {
+		return ""
+	}
+	hops := strings.Split(values[len(values)-1], ",")
+	return strings.TrimSpace(hops[len(hops)-1])
+}
+
 // adds context data to http request so we can pass
 func addToContextALB(ctx context.Context, req *http.Request, albRequest events.ALBTargetGroupRequest) *http.Request {
 	lc, _ := lambdacontext.FromContext(ctx)
@@ -3,7 +3,9 @@ package core_test
 import (
 	"context"
 	"encoding/base64"
+	"io/ioutil"
 	"math/rand"
+	"strconv"
 	"strings"
 
 	"github.com/awslabs/aws-lambda-go-api-proxy/core"
@@ -69,7 +71,27 @@ var _ = Describe("RequestAccessorALB tests", func() {
 			Expect("POST").To(Equal(httpReq.Method))
 
 			Expect(err).To(BeNil())
+			Expect(httpReq.ContentLength).To(Equal(int64(len(binaryBody))))
 
+			bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(binaryBody).To(Equal(bodyBytes))
+		})
+
+		encodedQueryRequest := getALBProxyRequest("/hello", "GET", getALBRequestContext(), false, hdr, bdy, nil, mvh, map[string][]string{
+			"q":      {"caf%C3%A9+au+lait"},
+			"filter": {"a%26b", "c"},
+		})
+		It("Does not encode ALB query parameters a second time", func() {
+			for i := 0; i < 10; i++ {
+				httpReq, err := accessor.EventToRequestWithContext(context.Background(), encodedQueryRequest)
+				Expect(err).To(BeNil())
+				Expect(httpReq.RequestURI).To(Equal("/hello?filter=a%26b&filter=c&q=caf%C3%A9+au+lait"))
+
+				query := httpReq.URL.Query()
+				Expect(query["q"]).To(Equal([]string{"café au lait"}))
+				Expect(query["filter"]).To(Equal([]string{"a&b", "c"}))
+			}
 		})
 
 		mqsRequest := getALBProxyRequest("/hello", "GET", getALBRequestContext(), false, hdr, bdy, qs, mvh, nil)
@@ -132,7 +154,11 @@ var _ = Describe("RequestAccessorALB tests", func() {
 			Expect("GET").To(Equal(httpReq.Method))
 
 			headers := httpReq.Header
-			Expect(3).To(Equal(len(headers)))
+			Expect(4).To(Equal(len(headers)))
+
+			// Content-Length is set from the decoded body
+			Expect(headers.Get("Content-Length")).To(Equal(strconv.Itoa(len(bdy))))
+			headers.Del("Content-Length")
 
 			for k, value := range headers {
 				Expect(value).To(Equal(mvhRequest.MultiValueHeaders[strings.ToLower(k)]))
@@ -152,7 +178,11 @@ var _ = Describe("RequestAccessorALB tests", func() {
 			Expect("GET").To(Equal(httpReq.Method))
 
 			headers := httpReq.Header
-			Expect(3).To(Equal(len(headers)))
+			Expect(4).To(Equal(len(headers)))
+
+			// Content-Length is set from the decoded body
+			Expect(headers.Get("Content-Length")).To(Equal(strconv.Itoa(len(bdy))))
+			headers.Del("Content-Length")
 
 			for k, value := range headers {
 				Expect(value).To(Equal(mvhRequest.MultiValueHeaders[strings.ToLower(k)]))
@@ -176,9 +206,9 @@ var _ = Describe("RequestAccessorALB tests", func() {
 
 		It("Populates context header correctly", func() {
 			// calling old method to verify reverse compatibility
-			httpReq, err := accessor.ProxyEventToHTTPRequest(contextRequest)
+			httpReq, err := core.NewRequestAccessorALB(core.WithContextHeaders(true)).ProxyEventToHTTPRequest(contextRequest)
 			Expect(err).To(BeNil())
-			Expect(4).To(Equal(len(httpReq.Header)))
+			Expect(5).To(Equal(len(httpReq.Header)))
 			Expect(httpReq.Header.Get(core.ALBContextHeader)).ToNot(BeNil())
 		})
 	})
@@ -229,8 +259,8 @@ var _ = Describe("RequestAccessorALB tests", func() {
 			Expect(headerContext).ToNot(BeNil())
 			Expect("arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/lambda-target/abcdefgh").To(Equal(headerContext.ELB.TargetGroupArn))
 			proxyContext, ok := core.GetTargetGroupRequetFromContextALB(httpReq.Context())
-			// should fail because using header proxy method
-			Expect(ok).To(BeFalse())
+			// stored in the context by the header proxy method as well
+			Expect(ok).To(BeTrue())
 
 			httpReq, err = accessor.EventToRequestWithContext(context.Background(), contextRequest)
 			Expect(err).To(BeNil())
@@ -246,8 +276,8 @@ var _ = Describe("RequestAccessorALB tests", func() {
 			Expect(err).To(BeNil())
 
 			headerContext, err = accessor.GetContextALB(httpReq)
-			// should fail as new context method doesn't populate headers
-			Expect(err).ToNot(BeNil())
+			// read from the context as the new context method doesn't populate headers
+			Expect(err).To(BeNil())
 			proxyContext, ok = core.GetTargetGroupRequetFromContextALB(httpReq.Context())
 			Expect(ok).To(BeTrue())
 			Expect("arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/lambda-target/abcdefgh").To(Equal(proxyContext.ELB.TargetGroupArn))
@@ -62,6 +62,45 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect(err).To(BeNil())
 			Expect(len(binaryBody)).To(Equal(len(bodyBytes)))
 			Expect(binaryBody).To(Equal(bodyBytes))
+			Expect(httpReq.ContentLength).To(Equal(int64(len(binaryBody))))
+		})
+
+		It("Replays a base64 encoded body with GetBody", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), binaryRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.GetBody).ToNot(BeNil())
+
+			_, err = ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+
+			replay, err := httpReq.GetBody()
+			Expect(err).To(BeNil())
+			bodyBytes, err := ioutil.ReadAll(replay)
+			Expect(err).To(BeNil())
+			Expect(binaryBody).To(Equal(bodyBytes))
+		})
+
+		paddedRequest := getProxyRequest("/hello", "POST")
+		paddedRequest.Body = base64.StdEncoding.EncodeToString([]byte("hello"))
+		paddedRequest.IsBase64Encoded = true
+
+		It("Computes the content length of padded base64 bodies", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), paddedRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.ContentLength).To(Equal(int64(5)))
+
+			bodyBytes, err := ioutil.ReadAll(httpReq.Body)
+			Expect(err).To(BeNil())
+			Expect(string(bodyBytes)).To(Equal("hello"))
+		})
+
+		invalidBase64Request := getProxyRequest("/hello", "POST")
+		invalidBase64Request.Body = "aGVsbG8"
+		invalidBase64Request.IsBase64Encoded = true
+
+		It("Rejects base64 bodies with an invalid length", func() {
+			_, err := accessor.EventToRequestWithContext(context.Background(), invalidBase64Request)
+			Expect(err).ToNot(BeNil())
 		})
 
 		mqsRequest := getProxyRequest("/hello", "GET")
@@ -118,6 +157,20 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect("2").To(Equal(query["world"][0]))
 		})
 
+		orderedRequest := getProxyRequest("/hello", "GET")
+		orderedRequest.MultiValueQueryStringParameters = map[string][]string{
+			"zeta":  {"last"},
+			"alpha": {"b", "a"},
+			"mid":   {"a b&c"},
+		}
+		It("Builds a deterministic query string", func() {
+			for i := 0; i < 10; i++ {
+				httpReq, err := accessor.EventToRequestWithContext(context.Background(), orderedRequest)
+				Expect(err).To(BeNil())
+				Expect(httpReq.RequestURI).To(Equal("/hello?alpha=b&alpha=a&mid=a+b%26c&zeta=last"))
+			}
+		})
+
 		mvhRequest := getProxyRequest("/hello", "GET")
 		mvhRequest.MultiValueHeaders = map[string][]string{
 			"hello": {"1"},
@@ -172,7 +225,7 @@ var _ = Describe("RequestAccessor tests", func() {
 
 		It("Populates context header correctly", func() {
 			// calling old method to verify reverse compatibility
-			httpReq, err := accessor.ProxyEventToHTTPRequest(contextRequest)
+			httpReq, err := core.NewRequestAccessor(core.WithContextHeaders(true)).ProxyEventToHTTPRequest(contextRequest)
 			Expect(err).To(BeNil())
 			Expect(2).To(Equal(len(httpReq.Header)))
 			Expect(httpReq.Header.Get(core.APIGwContextHeader)).ToNot(BeNil())
@@ -214,8 +267,8 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect("x").To(Equal(headerContext.RequestID))
 			Expect("x").To(Equal(headerContext.APIID))
 			proxyContext, ok := core.GetAPIGatewayContextFromContext(httpReq.Context())
-			// should fail because using header proxy method
-			Expect(ok).To(BeFalse())
+			// stored in the context by the header proxy method as well
+			Expect(ok).To(BeTrue())
 
 			// overwrite existing context header
 			contextRequestWithHeaders := getProxyRequest("orders", "GET")
@@ -244,8 +297,8 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect(err).To(BeNil())
 
 			headerContext, err = accessor.GetAPIGatewayContext(httpReq)
-			// should fail as new context method doesn't populate headers
-			Expect(err).ToNot(BeNil())
+			// read from the context as the new context method doesn't populate headers
+			Expect(err).To(BeNil())
 			proxyContext, ok = core.GetAPIGatewayContextFromContext(httpReq.Context())
 			Expect(ok).To(BeTrue())
 			Expect("x").To(Equal(proxyContext.APIID))
@@ -285,15 +338,16 @@ var _ = Describe("RequestAccessor tests", func() {
 			Expect(stageVars["var1"]).To(Equal("value1"))
 
 			stageVars, ok := core.GetStageVarsFromContext(httpReq.Context())
-			// not present in context
-			Expect(ok).To(BeFalse())
+			// stored in the context by the header proxy method as well
+			Expect(ok).To(BeTrue())
 
 			httpReq, err = accessor.EventToRequestWithContext(context.Background(), varsRequest)
 			Expect(err).To(BeNil())
 
 			stageVars, err = accessor.GetAPIGatewayStageVars(httpReq)
-			// should not be in headers
-			Expect(err).ToNot(BeNil())
+			// read from the context as they are not in headers
+			Expect(err).To(BeNil())
+			Expect(stageVars["var1"]).To(Equal("value1"))
 
 			stageVars, ok = core.GetStageVarsFromContext(httpReq.Context())
 			Expect(ok).To(BeTrue())
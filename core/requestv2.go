@@ -3,17 +3,11 @@
 package core
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/textproto"
-	"net/url"
-	"os"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -23,79 +17,91 @@ import (
 // RequestAccessorV2 objects give access to custom API Gateway properties
 // in the request.
 type RequestAccessorV2 struct {
-	stripBasePath string
+	options
 }
 
-// GetAPIGatewayContextV2 extracts the API Gateway context object from a
-// request's custom header.
+// NewRequestAccessorV2 returns a RequestAccessorV2 configured with the given
+// options.
+func NewRequestAccessorV2(opts ...Option) *RequestAccessorV2 {
+	r := &RequestAccessorV2{}
+	r.ApplyOptions(opts...)
+	return r
+}
+
+// NewProxyResponseWriterV2 returns a new ProxyResponseWriterV2 object that honours the
+// response options of the accessor, such as the binary content types.
+func (r *RequestAccessorV2) NewProxyResponseWriterV2() *ProxyResponseWriterV2 {
+	w := NewProxyResponseWriterV2()
+	w.responseOptions = r.responseOptions
+	return w
+}
+
+// GetAPIGatewayContextV2 extracts the API Gateway context object from the
+// request context, or from a request's custom header when the request was
+// not converted by this package.
 // Returns a populated events.APIGatewayProxyRequestContext object from
 // the request.
 func (r *RequestAccessorV2) GetAPIGatewayContextV2(req *http.Request) (events.APIGatewayV2HTTPRequestContext, error) {
+	if context, ok := GetAPIGatewayV2ContextFromContext(req.Context()); ok {
+		return context, nil
+	}
 	if req.Header.Get(APIGwContextHeader) == "" {
-		return events.APIGatewayV2HTTPRequestContext{}, errors.New("No context header in request")
+		return events.APIGatewayV2HTTPRequestContext{}, ErrNoContextHeader
 	}
 	context := events.APIGatewayV2HTTPRequestContext{}
 	err := json.Unmarshal([]byte(req.Header.Get(APIGwContextHeader)), &context)
 	if err != nil {
-		log.Println("Erorr while unmarshalling context")
-		log.Println(err)
-		return events.APIGatewayV2HTTPRequestContext{}, err
+		r.contextLogger(req.Context()).Error("Error while unmarshalling context", "error", err)
+		return events.APIGatewayV2HTTPRequestContext{}, &DecodingError{Name: APIGwContextHeader + " header", Err: err}
 	}
 	return context, nil
 }
 
-// GetAPIGatewayStageVars extracts the API Gateway stage variables from a
-// request's custom header.
+// GetAPIGatewayStageVars extracts the API Gateway stage variables from the
+// request context, or from a request's custom header when the request was
+// not converted by this package.
 // Returns a map[string]string of the stage variables and their values from
 // the request.
 func (r *RequestAccessorV2) GetAPIGatewayStageVars(req *http.Request) (map[string]string, error) {
+	if stageVars, ok := GetStageVarsFromContextV2(req.Context()); ok {
+		if stageVars == nil {
+			stageVars = make(map[string]string)
+		}
+		return stageVars, nil
+	}
 	stageVars := make(map[string]string)
 	if req.Header.Get(APIGwStageVarsHeader) == "" {
-		return stageVars, errors.New("No stage vars header in request")
+		return stageVars, ErrNoStageVarsHeader
 	}
 	err := json.Unmarshal([]byte(req.Header.Get(APIGwStageVarsHeader)), &stageVars)
 	if err != nil {
-		log.Println("Erorr while unmarshalling stage variables")
-		log.Println(err)
-		return stageVars, err
+		r.contextLogger(req.Context()).Error("Error while unmarshalling stage variables", "error", err)
+		return stageVars, &DecodingError{Name: APIGwStageVarsHeader + " header", Err: err}
 	}
 	return stageVars, nil
 }
 
-// StripBasePath instructs the RequestAccessor object that the given base
-// path should be removed from the request path before sending it to the
-// framework for routing. This is used when API Gateway is configured with
-// base path mappings in custom domain names.
-func (r *RequestAccessorV2) StripBasePath(basePath string) string {
-	if strings.Trim(basePath, " ") == "" {
-		r.stripBasePath = ""
-		return ""
-	}
-
-	newBasePath := basePath
-	if !strings.HasPrefix(newBasePath, "/") {
-		newBasePath = "/" + newBasePath
-	}
-
-	if strings.HasSuffix(newBasePath, "/") {
-		newBasePath = newBasePath[:len(newBasePath)-1]
-	}
-
-	r.stripBasePath = newBasePath
-
-	return newBasePath
-}
-
 // ProxyEventToHTTPRequest converts an API Gateway proxy event into a http.Request object.
-// Returns the populated http request with additional two custom headers for the stage variables and API Gateway context.
+// Returns the populated http request with the stage variables and API Gateway context as part of its context,
+// and in two custom headers when they are enabled with WithContextHeaders.
 // To access these properties use the GetAPIGatewayStageVars and GetAPIGatewayContext method of the RequestAccessor object.
 func (r *RequestAccessorV2) ProxyEventToHTTPRequest(req events.APIGatewayV2HTTPRequest) (*http.Request, error) {
 	httpRequest, err := r.EventToRequest(req)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
-	return addToHeaderV2(httpRequest, req)
+	httpRequest = addToContextV2(httpRequest.Context(), httpRequest, req)
+	if !r.contextHeaders {
+		// headers sent by the client must not be mistaken for ours
+		httpRequest.Header.Del(APIGwStageVarsHeader)
+		httpRequest.Header.Del(APIGwContextHeader)
+		return httpRequest, nil
+	}
+	httpRequest, err = addToHeaderV2(httpRequest, req, r.contextLogger(httpRequest.Context()))
+	if err != nil {
+		return nil, &ConversionError{Err: err}
+	}
+	return httpRequest, nil
 }
 
 // EventToRequestWithContext converts an API Gateway proxy event and context into an http.Request object.
@@ -104,22 +110,29 @@ func (r *RequestAccessorV2) ProxyEventToHTTPRequest(req events.APIGatewayV2HTTPR
 func (r *RequestAccessorV2) EventToRequestWithContext(ctx context.Context, req events.APIGatewayV2HTTPRequest) (*http.Request, error) {
 	httpRequest, err := r.EventToRequest(req)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
-	return addToContextV2(ctx, httpRequest, req), nil
+	return copyContextValues(httpRequest, addToContextV2(ctx, httpRequest, req)), nil
 }
 
 // EventToRequest converts an API Gateway proxy event into an http.Request object.
-// Returns the populated request maintaining headers
+// Returns the populated request maintaining headers. Failures are returned as
+// a ConversionError wrapping their cause.
 func (r *RequestAccessorV2) EventToRequest(req events.APIGatewayV2HTTPRequest) (*http.Request, error) {
-	decodedBody := []byte(req.Body)
-	if req.IsBase64Encoded {
-		base64Body, err := base64.StdEncoding.DecodeString(req.Body)
-		if err != nil {
-			return nil, err
-		}
-		decodedBody = base64Body
+	httpRequest, err := r.eventToRequest(req)
+	if err != nil {
+		return nil, &ConversionError{Err: err}
+	}
+	return httpRequest, nil
+}
+
+func (r *RequestAccessorV2) eventToRequest(req events.APIGatewayV2HTTPRequest) (*http.Request, error) {
+	if err := validateMethod("requestContext.http.method", req.RequestContext.HTTP.Method); err != nil {
+		return nil, err
+	}
+	body, err := newEventBody(req.Body, req.IsBase64Encoded)
+	if err != nil {
+		return nil, &InvalidEventError{Field: "body", Err: &DecodingError{Name: "base64 body", Err: err}}
 	}
 
 	path := req.RawPath
@@ -129,75 +142,78 @@ func (r *RequestAccessorV2) EventToRequest(req events.APIGatewayV2HTTPRequest) (
 		path = req.RequestContext.HTTP.Path
 	}
 
-	if r.stripBasePath != "" && len(r.stripBasePath) > 1 {
-		if strings.HasPrefix(path, r.stripBasePath) {
-			path = strings.Replace(path, r.stripBasePath, "", 1)
-		}
+	strip, basePath := r.stripBasePath, ""
+	if r.autoBasePath {
+		strip, basePath = r.basePathV2(req, path)
 	}
-	if !strings.HasPrefix(path, "/") {
-		path = "/" + path
-	}
-	serverAddress := "https://" + req.RequestContext.DomainName
-	if customAddress, ok := os.LookupEnv(CustomHostVariable); ok {
-		serverAddress = customAddress
-	}
-	path = serverAddress + path
-
-	if len(req.RawQueryString) > 0 {
-		path += "?" + req.RawQueryString
-	} else if len(req.QueryStringParameters) > 0 {
-		values := url.Values{}
-		for key, value := range req.QueryStringParameters {
-			values.Add(key, value)
-		}
-		path += "?" + values.Encode()
+	serverAddress := r.serverAddress(req.RequestContext.DomainName)
+	queryString := req.RawQueryString
+	if len(queryString) == 0 && len(req.QueryStringParameters) > 0 {
+		queryString = queryStringV2(req.QueryStringParameters)
 	}
+	path = requestURL(serverAddress, trimBasePath(path, strip), queryString)
 
 	httpRequest, err := http.NewRequest(
 		strings.ToUpper(req.RequestContext.HTTP.Method),
 		path,
-		bytes.NewReader(decodedBody),
+		nil,
 	)
 
 	if err != nil {
-		fmt.Printf("Could not convert request %s:%s to http.Request\n", req.RequestContext.HTTP.Method, req.RequestContext.HTTP.Path)
-		log.Println(err)
-		return nil, err
+		return nil, newRequestError(serverAddress, err)
 	}
 
-	httpRequest.RemoteAddr = req.RequestContext.HTTP.SourceIP
-
-	for _, cookie := range req.Cookies {
-		httpRequest.Header.Add("Cookie", cookie)
+	// API Gateway moves the cookies into their own field; they are joined
+	// back into a single Cookie header as required by RFC 6265.
+	if len(req.Cookies) > 0 {
+		httpRequest.Header.Set("Cookie", strings.Join(req.Cookies, "; "))
 	}
 
-	singletonHeaders, headers := splitSingletonHeaders(req.Headers)
+	singletonHeaders, headers := splitSingletonHeaders(req.Headers, r.neverSplitHeaders)
 
 	for headerKey, headerValue := range singletonHeaders {
+		if len(req.Cookies) > 0 && textproto.CanonicalMIMEHeaderKey(headerKey) == "Cookie" {
+			continue
+		}
 		httpRequest.Header.Add(headerKey, headerValue)
 	}
 
 	for headerKey, headerValue := range headers {
-		for _, val := range strings.Split(headerValue, ",") {
-			httpRequest.Header.Add(headerKey, strings.Trim(val, " "))
+		for _, val := range splitHeaderValue(headerValue) {
+			httpRequest.Header.Add(headerKey, val)
+		}
+	}
+
+	bodyLimit, err := r.prepareBody(httpRequest, body)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizeRequest(httpRequest, req.RequestContext.HTTP.Protocol)
+
+	httpRequest.RemoteAddr = r.remoteAddr(req.RequestContext.HTTP.SourceIP, httpRequest.Header)
+
+	if r.decompressBody {
+		if err := decompressRequestBody(httpRequest, r.decompressedBodyLimit(bodyLimit)); err != nil {
+			return nil, err
 		}
 	}
 
 	httpRequest.RequestURI = httpRequest.URL.RequestURI()
 
-	return httpRequest, nil
+	return withLogInfo(withBasePath(httpRequest, basePath), eventTypeAPIGatewayV2, req.RouteKey), nil
 }
 
-func addToHeaderV2(req *http.Request, apiGwRequest events.APIGatewayV2HTTPRequest) (*http.Request, error) {
+func addToHeaderV2(req *http.Request, apiGwRequest events.APIGatewayV2HTTPRequest, logger *slog.Logger) (*http.Request, error) {
 	stageVars, err := json.Marshal(apiGwRequest.StageVariables)
 	if err != nil {
-		log.Println("Could not marshal stage variables for custom header")
+		logger.Error("Could not marshal stage variables for custom header", "error", err)
 		return nil, err
 	}
 	req.Header.Add(APIGwStageVarsHeader, string(stageVars))
 	apiGwContext, err := json.Marshal(apiGwRequest.RequestContext)
 	if err != nil {
-		log.Println("Could not Marshal API GW context for custom header")
+		logger.Error("Could not marshal API GW context for custom header", "error", err)
 		return req, err
 	}
 	req.Header.Add(APIGwContextHeader, string(apiGwContext))
@@ -236,13 +252,15 @@ type requestContextV2 struct {
 }
 
 // splitSingletonHeaders splits the headers into single-value headers and other,
-// multi-value capable, headers.
+// multi-value capable, headers. Headers in neverSplit are treated as
+// single-value headers in addition to the default set.
 // Returns (single-value headers, multi-value-capable headers)
-func splitSingletonHeaders(headers map[string]string) (map[string]string, map[string]string) {
+func splitSingletonHeaders(headers map[string]string, neverSplit map[string]bool) (map[string]string, map[string]string) {
 	singletons := make(map[string]string)
 	multitons := make(map[string]string)
 	for headerKey, headerValue := range headers {
-		if ok := singletonHeaders[textproto.CanonicalMIMEHeaderKey(headerKey)]; ok {
+		canonicalKey := textproto.CanonicalMIMEHeaderKey(headerKey)
+		if singletonHeaders[canonicalKey] || neverSplit[canonicalKey] {
 			singletons[headerKey] = headerValue
 		} else {
 			multitons[headerKey] = headerValue
@@ -252,8 +270,47 @@ func splitSingletonHeaders(headers map[string]string) (map[string]string, map[st
 	return singletons, multitons
 }
 
+// splitHeaderValue splits a comma separated header value into its list
+// elements as defined in RFC 9110 section 5.6.1. Commas inside quoted strings
+// do not separate elements, optional whitespace around elements is removed and
+// empty elements are ignored.
+func splitHeaderValue(value string) []string {
+	values := make([]string, 0, 1)
+	quoted, escaped := false, false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			values = appendHeaderElement(values, value[start:i])
+			start = i + 1
+		}
+	}
+	values = appendHeaderElement(values, value[start:])
+
+	if len(values) == 0 {
+		values = append(values, strings.Trim(value, " \t"))
+	}
+	return values
+}
+
+func appendHeaderElement(values []string, element string) []string {
+	element = strings.Trim(element, " \t")
+	if element == "" {
+		return values
+	}
+	return append(values, element)
+}
+
 // singletonHeaders is a set of headers, that only accept a single
-// value which may be comma separated (according to RFC 7230)
+// value which may be comma separated (according to RFC 7230), or whose
+// values commonly contain commas that do not separate list elements,
+// such as dates.
 var singletonHeaders = map[string]bool{
 	"Content-Type":        true,
 	"Content-Disposition": true,
@@ -268,4 +325,10 @@ var singletonHeaders = map[string]bool{
 	"From":                true,
 	"Location":            true,
 	"Max-Forwards":        true,
+	"Cookie":              true,
+	"Date":                true,
+	"Expires":             true,
+	"If-Range":            true,
+	"Last-Modified":       true,
+	"Retry-After":         true,
 }
@@ -3,6 +3,7 @@ package core_test
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"github.com/onsi/gomega/gstruct"
 	"io/ioutil"
 	"math/rand"
@@ -63,6 +64,7 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect(err).To(BeNil())
 			Expect(len(binaryBody)).To(Equal(len(bodyBytes)))
 			Expect(binaryBody).To(Equal(bodyBytes))
+			Expect(httpReq.ContentLength).To(Equal(int64(len(binaryBody))))
 		})
 
 		mqsRequest := getProxyRequestV2("/hello", "GET")
@@ -116,6 +118,18 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect("2").To(Equal(query["world"][0]))
 		})
 
+		repeatedQsRequest := getProxyRequestV2("/hello", "GET")
+		repeatedQsRequest.QueryStringParameters = map[string]string{
+			"world": "2,3",
+			"hello": "1",
+		}
+		It("Recovers repeated query string parameters", func() {
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), repeatedQsRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.RequestURI).To(Equal("/hello?hello=1&world=2&world=3"))
+			Expect(httpReq.URL.Query()["world"]).To(Equal([]string{"2", "3"}))
+		})
+
 		mvhRequest := getProxyRequestV2("/hello", "GET")
 		mvhRequest.Headers = map[string]string{
 			"hello": "1",
@@ -200,7 +214,7 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 
 		It("Populates context header correctly", func() {
 			// calling old method to verify reverse compatibility
-			httpReq, err := accessor.ProxyEventToHTTPRequest(contextRequest)
+			httpReq, err := core.NewRequestAccessorV2(core.WithContextHeaders(true)).ProxyEventToHTTPRequest(contextRequest)
 			Expect(err).To(BeNil())
 			Expect(2).To(Equal(len(httpReq.Header)))
 			Expect(httpReq.Header.Get(core.APIGwContextHeader)).ToNot(BeNil())
@@ -242,8 +256,8 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect("x").To(Equal(headerContext.RequestID))
 			Expect("x").To(Equal(headerContext.APIID))
 			proxyContext, ok := core.GetAPIGatewayV2ContextFromContext(httpReq.Context())
-			// should fail because using header proxy method
-			Expect(ok).To(BeFalse())
+			// stored in the context by the header proxy method as well
+			Expect(ok).To(BeTrue())
 
 			httpReq, err = accessor.EventToRequestWithContext(context.Background(), contextRequest)
 			Expect(err).To(BeNil())
@@ -262,8 +276,8 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect(err).To(BeNil())
 
 			headerContext, err = accessor.GetAPIGatewayContextV2(httpReq)
-			// should fail as new context method doesn't populate headers
-			Expect(err).ToNot(BeNil())
+			// read from the context as the new context method doesn't populate headers
+			Expect(err).To(BeNil())
 			proxyContext, ok = core.GetAPIGatewayV2ContextFromContext(httpReq.Context())
 			Expect(ok).To(BeTrue())
 			Expect("x").To(Equal(proxyContext.APIID))
@@ -293,15 +307,16 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 			Expect("value2").To(Equal(stageVars["var2"]))
 
 			stageVars, ok := core.GetStageVarsFromContextV2(httpReq.Context())
-			// not present in context
-			Expect(ok).To(BeFalse())
+			// stored in the context by the header proxy method as well
+			Expect(ok).To(BeTrue())
 
 			httpReq, err = accessor.EventToRequestWithContext(context.Background(), varsRequest)
 			Expect(err).To(BeNil())
 
 			stageVars, err = accessor.GetAPIGatewayStageVars(httpReq)
-			// should not be in headers
-			Expect(err).ToNot(BeNil())
+			// read from the context as they are not in headers
+			Expect(err).To(BeNil())
+			Expect(stageVars["var1"]).To(Equal("value1"))
 
 			stageVars, ok = core.GetStageVarsFromContextV2(httpReq.Context())
 			Expect(ok).To(BeTrue())
@@ -362,6 +377,89 @@ var _ = Describe("RequestAccessorV2 tests", func() {
 				"Value": Equal("123"),
 			})))
 		})
+
+		It("Joins multiple cookies into a single header", func() {
+			basicRequest := getProxyRequestV2("orders", "GET")
+			basicRequest.Cookies = []string{
+				"TestCookie=123",
+				"Session=abc",
+			}
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), basicRequest)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Values("Cookie")).To(Equal([]string{"TestCookie=123; Session=abc"}))
+			Expect(httpReq.Cookies()).To(HaveLen(2))
+			Expect(httpReq.Cookie("Session")).To(gstruct.PointTo(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+				"Value": Equal("abc"),
+			})))
+		})
+	})
+
+	Context("Header splitting", func() {
+		It("Does not split commas inside quoted strings", func() {
+			req := getProxyRequestV2("/hello", "GET")
+			req.Headers = map[string]string{
+				"if-none-match": `"abc,def", W/"x\"y,z"`,
+				"forwarded":     `for="[2001:db8::1]:4711";proto=https, for=192.0.2.60`,
+				"x-custom":      `"a, b",c,,d `,
+			}
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Values("If-None-Match")).To(Equal([]string{`"abc,def"`, `W/"x\"y,z"`}))
+			Expect(httpReq.Header.Values("Forwarded")).To(Equal([]string{`for="[2001:db8::1]:4711";proto=https`, "for=192.0.2.60"}))
+			Expect(httpReq.Header.Values("X-Custom")).To(Equal([]string{`"a, b"`, "c", "d"}))
+		})
+
+		It("Does not split date headers", func() {
+			req := getProxyRequestV2("/hello", "GET")
+			req.Headers = map[string]string{
+				"if-range": "Wed, 21 Oct 2015 07:28:00 GMT",
+				"date":     "Wed, 21 Oct 2015 07:28:00 GMT",
+			}
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Values("If-Range")).To(Equal([]string{"Wed, 21 Oct 2015 07:28:00 GMT"}))
+			Expect(httpReq.Header.Values("Date")).To(Equal([]string{"Wed, 21 Oct 2015 07:28:00 GMT"}))
+		})
+
+		It("Never splits configured headers", func() {
+			req := getProxyRequestV2("/hello", "GET")
+			req.Headers = map[string]string{
+				"x-signature": "keyId=abc, signature=def",
+				"accept":      "text/html, application/json",
+			}
+			accessor := core.RequestAccessorV2{}
+			accessor.NeverSplitHeaders("X-Signature")
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+			Expect(httpReq.Header.Values("X-Signature")).To(Equal([]string{"keyId=abc, signature=def"}))
+			Expect(httpReq.Header.Values("Accept")).To(Equal([]string{"text/html", "application/json"}))
+		})
+
+		It("Converts a real HTTP API payload", func() {
+			req := events.APIGatewayV2HTTPRequest{}
+			Expect(json.Unmarshal([]byte(httpAPIPayload), &req)).To(BeNil())
+
+			accessor := core.RequestAccessorV2{}
+			httpReq, err := accessor.EventToRequestWithContext(context.Background(), req)
+			Expect(err).To(BeNil())
+
+			Expect(httpReq.Method).To(Equal("POST"))
+			Expect(httpReq.URL.Path).To(Equal("/my/path"))
+			Expect(httpReq.Header.Values("Cookie")).To(Equal([]string{"cookie1=value1; cookie2=value2"}))
+			Expect(httpReq.Header.Values("Accept")).To(Equal([]string{
+				"text/html",
+				"application/xhtml+xml",
+				`application/xml;q=0.9;profile="a,b"`,
+				"*/*;q=0.8",
+			}))
+			Expect(httpReq.Header.Values("If-None-Match")).To(Equal([]string{`"33a64df5,v2"`, `"c3piozzzz"`}))
+			Expect(httpReq.Header.Values("If-Modified-Since")).To(Equal([]string{"Wed, 21 Oct 2015 07:28:00 GMT"}))
+			Expect(httpReq.Header.Values("User-Agent")).To(Equal([]string{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko)"}))
+			Expect(httpReq.Header.Values("X-Forwarded-For")).To(Equal([]string{"192.0.2.1", "198.51.100.7"}))
+		})
 	})
 })
 
@@ -386,3 +484,49 @@ func getRequestContextV2() events.APIGatewayV2HTTPRequestContext {
 		DomainName: "12abcdefgh.execute-api.us-east-2.amazonaws.com",
 	}
 }
+
+// httpAPIPayload is an HTTP API payload format version 2.0 event as delivered
+// by API Gateway.
+const httpAPIPayload = `{
+  "version": "2.0",
+  "routeKey": "$default",
+  "rawPath": "/my/path",
+  "rawQueryString": "parameter1=value1&parameter1=value2&parameter2=value",
+  "cookies": ["cookie1=value1", "cookie2=value2"],
+  "headers": {
+    "accept": "text/html,application/xhtml+xml,application/xml;q=0.9;profile=\"a,b\",*/*;q=0.8",
+    "content-type": "application/json",
+    "host": "r3pmxmplak.execute-api.us-east-2.amazonaws.com",
+    "if-modified-since": "Wed, 21 Oct 2015 07:28:00 GMT",
+    "if-none-match": "\"33a64df5,v2\", \"c3piozzzz\"",
+    "user-agent": "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko)",
+    "x-amzn-trace-id": "Root=1-5e6722a7-cc56xmpl46db7ae02d4da47e",
+    "x-forwarded-for": "192.0.2.1, 198.51.100.7",
+    "x-forwarded-port": "443",
+    "x-forwarded-proto": "https"
+  },
+  "queryStringParameters": {
+    "parameter1": "value1,value2",
+    "parameter2": "value"
+  },
+  "requestContext": {
+    "accountId": "123456789012",
+    "apiId": "r3pmxmplak",
+    "domainName": "r3pmxmplak.execute-api.us-east-2.amazonaws.com",
+    "domainPrefix": "r3pmxmplak",
+    "http": {
+      "method": "POST",
+      "path": "/my/path",
+      "protocol": "HTTP/1.1",
+      "sourceIp": "192.0.2.1",
+      "userAgent": "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko)"
+    },
+    "requestId": "JKJaXmPLvHcESHA=",
+    "routeKey": "$default",
+    "stage": "$default",
+    "time": "10/Mar/2020:05:16:23 +0000",
+    "timeEpoch": 1583817383220
+  },
+  "body": "{\"hello\":\"world\"}",
+  "isBase64Encoded": false
+}`
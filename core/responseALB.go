@@ -3,11 +3,11 @@
 package core
 
 import (
-	"bytes"
-	"encoding/base64"
-	"errors"
 	"net/http"
-	"unicode/utf8"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -15,98 +15,99 @@ import (
 // ProxyResponseWriter implements http.ResponseWriter and adds the method
 // necessary to return an events.ALBTargetGroupResponse object
 type ProxyResponseWriterALB struct {
-	headers    http.Header
-	body       bytes.Buffer
-	status     int
-	statusText string
-	observers  []chan<- bool
+	responseWriter
+
+	singleValueHeaders bool
+}
+
+// proxyResponseWriterALBPool holds released ProxyResponseWriterALB objects for
+// reuse.
+var proxyResponseWriterALBPool = sync.Pool{
+	New: func() any { return new(ProxyResponseWriterALB) },
 }
 
 // NewProxyResponseWriter returns a new ProxyResponseWriter object.
 // The object is initialized with an empty map of headers and a
 // status code of -1
 func NewProxyResponseWriterALB() *ProxyResponseWriterALB {
-	return &ProxyResponseWriterALB{
-		headers:    make(http.Header),
-		status:     defaultStatusCode,
-		statusText: http.StatusText(defaultStatusCode),
-		observers:  make([]chan<- bool, 0),
-	}
-
+	r := proxyResponseWriterALBPool.Get().(*ProxyResponseWriterALB)
+	r.prepare()
+	return r
 }
 
-func (r *ProxyResponseWriterALB) CloseNotify() <-chan bool {
-	ch := make(chan bool, 1)
-
-	r.observers = append(r.observers, ch)
-
-	return ch
-}
-
-func (r *ProxyResponseWriterALB) notifyClosed() {
-	for _, v := range r.observers {
-		v <- true
+// Release returns the writer to a pool so that NewProxyResponseWriterALB can
+// reuse it and its body buffer. The writer and the request bound to it must
+// not be used once it is released, while the proxy response returned by
+// GetProxyResponse remains valid. The adapters release their writers once the
+// response is produced. Calling Release more than once has no effect.
+func (r *ProxyResponseWriterALB) Release() {
+	if r.release() {
+		r.singleValueHeaders = false
+		proxyResponseWriterALBPool.Put(r)
 	}
 }
 
-// Header implementation from the http.ResponseWriter interface.
-func (r *ProxyResponseWriterALB) Header() http.Header {
-	return r.headers
-}
-
-// Write sets the response body in the object. If no status code
-// was set before with the WriteHeader method it sets the status
-// for the response to 200 OK.
-func (r *ProxyResponseWriterALB) Write(body []byte) (int, error) {
-	if r.status == defaultStatusCode {
-		r.status = http.StatusOK
+// BindRequest derives the context of req from its Lambda invocation context,
+// with a deadline set reserve before the function timeout, and ties the
+// context to the response writer. The context is cancelled once
+// GetProxyResponse produces the response. The headers of the response are
+// returned in the format of the target group the request was converted from.
+// It returns a copy of req that should be served to the handler.
+func (r *ProxyResponseWriterALB) BindRequest(req *http.Request, reserve time.Duration) *http.Request {
+	if multiValue, ok := req.Context().Value(multiValueHeadersKey{}).(bool); ok {
+		r.singleValueHeaders = !multiValue
 	}
-
-	// if the content type header is not set when we write the body we try to
-	// detect one and set it by default. If the content type cannot be detected
-	// it is automatically set to "application/octet-stream" by the
-	// DetectContentType method
-	if r.Header().Get(contentTypeHeaderKey) == "" {
-		r.Header().Add(contentTypeHeaderKey, http.DetectContentType(body))
-	}
-
-	return (&r.body).Write(body)
-}
-
-// WriteHeader sets a status code for the response. This method is used
-// for error responses.
-func (r *ProxyResponseWriterALB) WriteHeader(status int) {
-	r.status = status
+	return r.responseWriter.BindRequest(req, reserve)
 }
 
 // GetProxyResponse converts the data passed to the response writer into
-// an events.ALBTargetGroupResponse object.
+// an events.ALBTargetGroupResponse object. The headers are returned in the
+// MultiValueHeaders field when the request bound with BindRequest came from a
+// target group with multi-value headers enabled, or was not converted from an
+// ALB event, and in the Headers field otherwise.
 // Returns a populated proxy response object. If the response is invalid, for example
 // has no headers or an invalid status code returns an error.
 func (r *ProxyResponseWriterALB) GetProxyResponse() (events.ALBTargetGroupResponse, error) {
-	r.notifyClosed()
-
-	if r.status == defaultStatusCode {
-		return events.ALBTargetGroupResponse{}, errors.New("status code not set on response")
-	}
-
-	var output string
-	isBase64 := false
-
-	bb := (&r.body).Bytes()
-
-	if utf8.Valid(bb) {
-		output = string(bb)
-	} else {
-		output = base64.StdEncoding.EncodeToString(bb)
-		isBase64 = true
+	output, isBase64, err := r.proxyResponse()
+	if err != nil {
+		return events.ALBTargetGroupResponse{}, err
 	}
 
-	return events.ALBTargetGroupResponse{
+	resp := events.ALBTargetGroupResponse{
 		StatusCode:        r.status,
-		StatusDescription: http.StatusText(r.status),
-		MultiValueHeaders: http.Header(r.headers),
+		StatusDescription: statusDescriptionALB(r.status),
 		Body:              output,
 		IsBase64Encoded:   isBase64,
-	}, nil
+	}
+	if r.singleValueHeaders {
+		resp.Headers = singleValueHeadersALB(r.headers)
+	} else {
+		resp.MultiValueHeaders = r.returnHeaders()
+	}
+	return resp, nil
+}
+
+// statusDescriptionALB returns the status description of an ALB response,
+// made of the status code and its reason phrase, such as "200 OK".
+func statusDescriptionALB(status int) string {
+	return strings.TrimSpace(strconv.Itoa(status) + " " + http.StatusText(status))
+}
+
+// singleValueHeadersALB folds the response headers into single values for
+// target groups without multi-value headers. Repeated values are joined with
+// commas, except for Set-Cookie whose values cannot be combined; the load
+// balancer only supports one cookie in this mode and the last one is kept.
+func singleValueHeadersALB(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for headerKey, headerValue := range header {
+		if len(headerValue) == 0 {
+			continue
+		}
+		if strings.EqualFold("set-cookie", headerKey) {
+			headers[headerKey] = headerValue[len(headerValue)-1]
+			continue
+		}
+		headers[headerKey] = strings.Join(headerValue, ",")
+	}
+	return headers
 }
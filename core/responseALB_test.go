@@ -5,6 +5,9 @@ import (
 	"math/rand"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -44,9 +47,9 @@ var _ = Describe("ResponseWriterALB tests", func() {
 			Expect(http.StatusOK).To(Equal(response.status))
 		})
 
-		It("Forces the status to a new code", func() {
+		It("Keeps the status of the written response", func() {
 			response.WriteHeader(http.StatusAccepted)
-			Expect(http.StatusAccepted).To(Equal(response.status))
+			Expect(http.StatusOK).To(Equal(response.status))
 		})
 	})
 
@@ -62,7 +65,7 @@ var _ = Describe("ResponseWriterALB tests", func() {
 			Expect("application/json").To(Equal(resp.Header().Get("Content-Type")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResp.MultiValueHeaders)))
 			Expect("application/json").To(Equal(proxyResp.MultiValueHeaders["Content-Type"][0]))
 			Expect(xmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -75,7 +78,7 @@ var _ = Describe("ResponseWriterALB tests", func() {
 			Expect(true).To(Equal(strings.HasPrefix(resp.Header().Get("Content-Type"), "text/xml;")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResp.MultiValueHeaders)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResp.MultiValueHeaders["Content-Type"][0], "text/xml;")))
 			Expect(xmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -88,7 +91,7 @@ var _ = Describe("ResponseWriterALB tests", func() {
 			Expect(true).To(Equal(strings.HasPrefix(resp.Header().Get("Content-Type"), "text/html;")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResp.MultiValueHeaders)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResp.MultiValueHeaders["Content-Type"][0], "text/html;")))
 			Expect(htmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -101,7 +104,7 @@ var _ = Describe("ResponseWriterALB tests", func() {
 		It("Refuses empty responses with default status code", func() {
 			_, err := emtpyResponse.GetProxyResponse()
 			Expect(err).ToNot(BeNil())
-			Expect("status code not set on response").To(Equal(err.Error()))
+			Expect(err).To(MatchError(ErrStatusNotSet))
 		})
 
 		simpleResponse := NewProxyResponseWriterALB()
@@ -114,7 +117,7 @@ var _ = Describe("ResponseWriterALB tests", func() {
 
 			Expect("hello").To(Equal(proxyResponse.Body))
 			Expect(http.StatusOK).To(Equal(proxyResponse.StatusCode))
-			Expect(1).To(Equal(len(proxyResponse.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResponse.MultiValueHeaders)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResponse.MultiValueHeaders["Content-Type"][0], "text/plain")))
 			Expect(proxyResponse.IsBase64Encoded).To(BeFalse())
 		})
@@ -126,8 +129,8 @@ var _ = Describe("ResponseWriterALB tests", func() {
 		if err != nil {
 			Fail("Could not generate random binary body")
 		}
-		binaryResponse.Write(binaryBody)
 		binaryResponse.WriteHeader(http.StatusAccepted)
+		binaryResponse.Write(binaryBody)
 
 		It("Encodes binary responses correctly", func() {
 			proxyResponse, err := binaryResponse.GetProxyResponse()
@@ -138,7 +141,7 @@ var _ = Describe("ResponseWriterALB tests", func() {
 			Expect(base64.StdEncoding.EncodedLen(len(binaryBody))).To(Equal(len(proxyResponse.Body)))
 
 			Expect(base64.StdEncoding.EncodeToString(binaryBody)).To(Equal(proxyResponse.Body))
-			Expect(1).To(Equal(len(proxyResponse.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResponse.MultiValueHeaders)))
 			Expect("application/octet-stream").To(Equal(proxyResponse.MultiValueHeaders["Content-Type"][0]))
 			Expect(http.StatusAccepted).To(Equal(proxyResponse.StatusCode))
 		})
@@ -177,4 +180,57 @@ var _ = Describe("ResponseWriterALB tests", func() {
 		})
 	})
 
+	Context("Target group header settings", func() {
+		serve := func(event events.ALBTargetGroupRequest) events.ALBTargetGroupResponse {
+			accessor := NewRequestAccessorALB()
+			req, err := accessor.EventToRequest(event)
+			Expect(err).To(BeNil())
+
+			w := accessor.NewProxyResponseWriterALB()
+			w.BindRequest(req, time.Second)
+			w.Header().Add("Set-Cookie", "csrftoken=foobar")
+			w.Header().Add("Set-Cookie", "session_id=barfoo")
+			w.Header().Add("Vary", "Accept")
+			w.Header().Add("Vary", "Accept-Encoding")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("hello"))
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			return resp
+		}
+
+		It("Returns multi-value headers when they are enabled", func() {
+			resp := serve(events.ALBTargetGroupRequest{
+				HTTPMethod:        "GET",
+				Path:              "/hello",
+				MultiValueHeaders: map[string][]string{"accept": {"*/*"}},
+			})
+
+			Expect(resp.Headers).To(BeNil())
+			Expect(resp.MultiValueHeaders["Set-Cookie"]).To(Equal([]string{"csrftoken=foobar", "session_id=barfoo"}))
+			Expect(resp.MultiValueHeaders["Vary"]).To(Equal([]string{"Accept", "Accept-Encoding"}))
+		})
+
+		It("Returns single-value headers when multi-value headers are disabled", func() {
+			resp := serve(events.ALBTargetGroupRequest{
+				HTTPMethod: "GET",
+				Path:       "/hello",
+				Headers:    map[string]string{"accept": "*/*"},
+			})
+
+			Expect(resp.MultiValueHeaders).To(BeNil())
+			Expect(resp.Headers["Set-Cookie"]).To(Equal("session_id=barfoo"))
+			Expect(resp.Headers["Vary"]).To(Equal("Accept,Accept-Encoding"))
+			Expect(resp.Headers["Content-Type"]).To(HavePrefix("text/plain"))
+		})
+
+		It("Includes the status code in the status description", func() {
+			resp := serve(events.ALBTargetGroupRequest{HTTPMethod: "GET", Path: "/hello"})
+			Expect(resp.StatusDescription).To(Equal("201 Created"))
+
+			Expect(statusDescriptionALB(http.StatusOK)).To(Equal("200 OK"))
+			Expect(statusDescriptionALB(599)).To(Equal("599"))
+		})
+	})
 })
@@ -1,10 +1,14 @@
 package core
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/json"
 	"math/rand"
 	"net/http"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -44,9 +48,9 @@ var _ = Describe("ResponseWriter tests", func() {
 			Expect(http.StatusOK).To(Equal(response.status))
 		})
 
-		It("Forces the status to a new code", func() {
+		It("Keeps the status of the written response", func() {
 			response.WriteHeader(http.StatusAccepted)
-			Expect(http.StatusAccepted).To(Equal(response.status))
+			Expect(http.StatusOK).To(Equal(response.status))
 		})
 	})
 
@@ -62,7 +66,7 @@ var _ = Describe("ResponseWriter tests", func() {
 			Expect("application/json").To(Equal(resp.Header().Get("Content-Type")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResp.MultiValueHeaders)))
 			Expect("application/json").To(Equal(proxyResp.MultiValueHeaders["Content-Type"][0]))
 			Expect(xmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -75,7 +79,7 @@ var _ = Describe("ResponseWriter tests", func() {
 			Expect(true).To(Equal(strings.HasPrefix(resp.Header().Get("Content-Type"), "text/xml;")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResp.MultiValueHeaders)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResp.MultiValueHeaders["Content-Type"][0], "text/xml;")))
 			Expect(xmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -88,7 +92,7 @@ var _ = Describe("ResponseWriter tests", func() {
 			Expect(true).To(Equal(strings.HasPrefix(resp.Header().Get("Content-Type"), "text/html;")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResp.MultiValueHeaders)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResp.MultiValueHeaders["Content-Type"][0], "text/html;")))
 			Expect(htmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -101,7 +105,7 @@ var _ = Describe("ResponseWriter tests", func() {
 		It("Refuses empty responses with default status code", func() {
 			_, err := emtpyResponse.GetProxyResponse()
 			Expect(err).ToNot(BeNil())
-			Expect("Status code not set on response").To(Equal(err.Error()))
+			Expect(err).To(MatchError(ErrStatusNotSet))
 		})
 
 		simpleResponse := NewProxyResponseWriter()
@@ -114,7 +118,7 @@ var _ = Describe("ResponseWriter tests", func() {
 
 			Expect("hello").To(Equal(proxyResponse.Body))
 			Expect(http.StatusOK).To(Equal(proxyResponse.StatusCode))
-			Expect(1).To(Equal(len(proxyResponse.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResponse.MultiValueHeaders)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResponse.MultiValueHeaders["Content-Type"][0], "text/plain")))
 			Expect(proxyResponse.IsBase64Encoded).To(BeFalse())
 		})
@@ -126,8 +130,8 @@ var _ = Describe("ResponseWriter tests", func() {
 		if err != nil {
 			Fail("Could not generate random binary body")
 		}
-		binaryResponse.Write(binaryBody)
 		binaryResponse.WriteHeader(http.StatusAccepted)
+		binaryResponse.Write(binaryBody)
 
 		It("Encodes binary responses correctly", func() {
 			proxyResponse, err := binaryResponse.GetProxyResponse()
@@ -138,7 +142,7 @@ var _ = Describe("ResponseWriter tests", func() {
 			Expect(base64.StdEncoding.EncodedLen(len(binaryBody))).To(Equal(len(proxyResponse.Body)))
 
 			Expect(base64.StdEncoding.EncodeToString(binaryBody)).To(Equal(proxyResponse.Body))
-			Expect(1).To(Equal(len(proxyResponse.MultiValueHeaders)))
+			Expect(2).To(Equal(len(proxyResponse.MultiValueHeaders)))
 			Expect("application/octet-stream").To(Equal(proxyResponse.MultiValueHeaders["Content-Type"][0]))
 			Expect(http.StatusAccepted).To(Equal(proxyResponse.StatusCode))
 		})
@@ -177,4 +181,132 @@ var _ = Describe("ResponseWriter tests", func() {
 		})
 	})
 
+	Context("Discard oversized bodies", func() {
+		write := func(w *ProxyResponseWriter, chunk string, n int) {
+			for i := 0; i < n; i++ {
+				w.Write([]byte(chunk))
+			}
+		}
+
+		It("Estimates the size of the encoded payload", func() {
+			opt := WithResponseSizeLimit(ResponseSizeLimit{MaxSize: 1000})
+
+			w := NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/plain")
+			write(w, strings.Repeat("a", 100), 8)
+			Expect(w.overflowed).To(BeFalse())
+			Expect(w.body.Len()).To(Equal(800))
+
+			// 800 bytes grow to 1068 once base64 encoded
+			w = NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "application/octet-stream")
+			write(w, strings.Repeat("\xff", 100), 8)
+			Expect(w.overflowed).To(BeTrue())
+			Expect(w.body.Len()).To(Equal(0))
+
+			// runes split between chunks do not make text binary
+			w = NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/plain")
+			write(w, "a"+strings.Repeat("é", 49)+"\xc3", 8)
+			Expect(w.overflowed).To(BeFalse())
+
+			w = NewRequestAccessor(opt).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Security-Policy", strings.Repeat("a", 300))
+			write(w, strings.Repeat("a", 100), 8)
+			Expect(w.overflowed).To(BeTrue())
+		})
+
+		It("Counts the JSON escaping of the payload", func() {
+			for _, s := range []string{
+				"hello", `{"quoted":"\\path"}`, "line\nbreak\ttab\r", "\x00\x1f\b\f",
+				"héllo wörld", "\u2028\u2029", "\xff\xc3", "<a href=\"x\">&</a>",
+			} {
+				// the Lambda runtime does not escape HTML by default
+				var marshalled bytes.Buffer
+				enc := json.NewEncoder(&marshalled)
+				enc.SetEscapeHTML(false)
+				Expect(enc.Encode(s)).To(BeNil())
+				Expect(jsonStringLen(s)).To(BeNumerically(">=", marshalled.Len()-3), s)
+			}
+			Expect(jsonStringLen(`"\`)).To(Equal(4))
+		})
+
+		It("Discards oversized bodies of handlers served with a deadline", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/large", nil)
+			Expect(err).To(BeNil())
+
+			accessor := NewRequestAccessor(WithResponseSizeLimit(ResponseSizeLimit{MaxSize: 1000}))
+			w := accessor.NewProxyResponseWriter()
+			req = w.BindRequest(req, 0)
+			Expect(accessor.Serve(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "text/plain")
+				for i := 0; i < 100; i++ {
+					rw.Write([]byte(strings.Repeat("a", 100)))
+				}
+			}), w, req)).To(BeNil())
+			Expect(w.overflowed).To(BeTrue())
+			Expect(w.body.Len()).To(Equal(0))
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+		})
+
+		It("Caps the bodies buffered for compression or upload", func() {
+			w := NewRequestAccessor(
+				WithResponseSizeLimit(ResponseSizeLimit{MaxSize: 1000}),
+				WithCompression(Compression{}),
+			).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/plain")
+			write(w, strings.Repeat("a", 1000), 4)
+			Expect(w.overflowed).To(BeFalse())
+			Expect(w.body.Len()).To(Equal(4000))
+			write(w, "a", 1)
+			Expect(w.overflowed).To(BeTrue())
+			Expect(w.body.Len()).To(Equal(0))
+
+			w = NewRequestAccessor(WithResponseSizeLimit(ResponseSizeLimit{
+				MaxSize:       1000,
+				MaxBufferSize: 2000,
+				Store:         &FileObjectStore{Dir: "/nonexistent"},
+			})).NewProxyResponseWriter()
+			w.Header().Set("Content-Type", "text/plain")
+			write(w, strings.Repeat("a", 1000), 3)
+			Expect(w.overflowed).To(BeTrue())
+
+			resp, err := w.GetProxyResponse()
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+		})
+	})
+
+	Context("Request context binding", func() {
+		It("Cancels the request context once the response is produced", func() {
+			deadline := time.Now().Add(10 * time.Second)
+			lambdaCtx, lambdaCancel := context.WithDeadline(context.Background(), deadline)
+			defer lambdaCancel()
+
+			req, err := http.NewRequestWithContext(lambdaCtx, http.MethodGet, "/hello", nil)
+			Expect(err).To(BeNil())
+
+			response := NewProxyResponseWriter()
+			req = response.BindRequest(req, time.Second)
+			closed := response.CloseNotify()
+
+			reqDeadline, ok := req.Context().Deadline()
+			Expect(ok).To(BeTrue())
+			Expect(reqDeadline).To(Equal(deadline.Add(-time.Second)))
+			Expect(req.Context().Err()).To(BeNil())
+
+			response.WriteHeader(http.StatusNoContent)
+			_, err = response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect(req.Context().Err()).To(Equal(context.Canceled))
+			Eventually(closed).Should(Receive())
+		})
+	})
 })
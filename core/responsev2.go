@@ -3,12 +3,10 @@
 package core
 
 import (
-	"bytes"
-	"encoding/base64"
-	"errors"
 	"net/http"
+	"net/textproto"
 	"strings"
-	"unicode/utf8"
+	"sync"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -16,100 +14,50 @@ import (
 // ProxyResponseWriterV2 implements http.ResponseWriter and adds the method
 // necessary to return an events.APIGatewayProxyResponse object
 type ProxyResponseWriterV2 struct {
-	headers   http.Header
-	body      bytes.Buffer
-	status    int
-	observers []chan<- bool
+	responseWriter
+}
+
+// proxyResponseWriterV2Pool holds released ProxyResponseWriterV2 objects for
+// reuse.
+var proxyResponseWriterV2Pool = sync.Pool{
+	New: func() any { return new(ProxyResponseWriterV2) },
 }
 
 // NewProxyResponseWriter returns a new ProxyResponseWriter object.
 // The object is initialized with an empty map of headers and a
 // status code of -1
 func NewProxyResponseWriterV2() *ProxyResponseWriterV2 {
-	return &ProxyResponseWriterV2{
-		headers:   make(http.Header),
-		status:    defaultStatusCode,
-		observers: make([]chan<- bool, 0),
-	}
-
+	r := proxyResponseWriterV2Pool.Get().(*ProxyResponseWriterV2)
+	r.prepare()
+	return r
 }
 
-func (r *ProxyResponseWriterV2) CloseNotify() <-chan bool {
-	ch := make(chan bool, 1)
-
-	r.observers = append(r.observers, ch)
-
-	return ch
-}
-
-func (r *ProxyResponseWriterV2) notifyClosed() {
-	for _, v := range r.observers {
-		v <- true
+// Release returns the writer to a pool so that NewProxyResponseWriterV2 can
+// reuse it and its body buffer. The writer and the request bound to it must
+// not be used once it is released, while the proxy response returned by
+// GetProxyResponse remains valid. The adapters release their writers once the
+// response is produced. Calling Release more than once has no effect.
+func (r *ProxyResponseWriterV2) Release() {
+	if r.release() {
+		proxyResponseWriterV2Pool.Put(r)
 	}
 }
 
-// Header implementation from the http.ResponseWriter interface.
-func (r *ProxyResponseWriterV2) Header() http.Header {
-	return r.headers
-}
-
-// Write sets the response body in the object. If no status code
-// was set before with the WriteHeader method it sets the status
-// for the response to 200 OK.
-func (r *ProxyResponseWriterV2) Write(body []byte) (int, error) {
-	if r.status == defaultStatusCode {
-		r.status = http.StatusOK
-	}
-
-	// if the content type header is not set when we write the body we try to
-	// detect one and set it by default. If the content type cannot be detected
-	// it is automatically set to "application/octet-stream" by the
-	// DetectContentType method
-	if r.Header().Get(contentTypeHeaderKey) == "" {
-		r.Header().Add(contentTypeHeaderKey, http.DetectContentType(body))
-	}
-
-	return (&r.body).Write(body)
-}
-
-// WriteHeader sets a status code for the response. This method is used
-// for error responses.
-func (r *ProxyResponseWriterV2) WriteHeader(status int) {
-	r.status = status
-}
-
 // GetProxyResponse converts the data passed to the response writer into
-// an events.APIGatewayProxyResponse object.
+// an events.APIGatewayProxyResponse object. Repeated headers are folded as
+// described in headersV2.
 // Returns a populated proxy response object. If the response is invalid, for example
 // has no headers or an invalid status code returns an error.
 func (r *ProxyResponseWriterV2) GetProxyResponse() (events.APIGatewayV2HTTPResponse, error) {
-	r.notifyClosed()
-
-	if r.status == defaultStatusCode {
-		return events.APIGatewayV2HTTPResponse{}, errors.New("Status code not set on response")
-	}
-
-	var output string
-	isBase64 := false
-
-	bb := (&r.body).Bytes()
-
-	if utf8.Valid(bb) {
-		output = string(bb)
-	} else {
-		output = base64.StdEncoding.EncodeToString(bb)
-		isBase64 = true
+	output, isBase64, err := r.proxyResponse()
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{}, err
 	}
 
-	headers := make(map[string]string)
-	cookies := make([]string, 0)
-
-	for headerKey, headerValue := range http.Header(r.headers) {
-		if strings.EqualFold("set-cookie", headerKey) {
-			cookies = append(cookies, headerValue...)
-			continue
-		}
-		headers[headerKey] = strings.Join(headerValue, ",")
+	headers, cookies, dropped := headersV2(r.headers)
+	for _, headerKey := range dropped {
+		r.routeLogger(r.invocationCtx, r.request).Warn("Response header has several values, which cannot be combined: only the first one is returned",
+			"header", headerKey, "values", len(r.headers[headerKey]))
 	}
 
 	return events.APIGatewayV2HTTPResponse{
@@ -120,3 +68,59 @@ func (r *ProxyResponseWriterV2) GetProxyResponse() (events.APIGatewayV2HTTPRespo
 		Cookies:         cookies,
 	}, nil
 }
+
+// singletonResponseHeaders are the response fields defined with a single
+// value, whose repeated values cannot be combined into one without producing
+// an invalid value. The values of other fields, lists such as
+// WWW-Authenticate or Link in RFC 9110 and RFC 8288 as well as unknown
+// fields, are combined with commas.
+var singletonResponseHeaders = map[string]bool{
+	"Access-Control-Allow-Credentials": true,
+	"Access-Control-Allow-Origin":      true,
+	"Access-Control-Max-Age":           true,
+	"Age":                              true,
+	"Content-Disposition":              true,
+	"Content-Length":                   true,
+	"Content-Location":                 true,
+	"Content-Range":                    true,
+	"Content-Type":                     true,
+	"Date":                             true,
+	"Etag":                             true,
+	"Expires":                          true,
+	"Last-Modified":                    true,
+	"Location":                         true,
+	"Referrer-Policy":                  true,
+	"Retry-After":                      true,
+	"Server":                           true,
+	"Strict-Transport-Security":        true,
+	"X-Content-Type-Options":           true,
+	"X-Frame-Options":                  true,
+}
+
+// headersV2 folds the response headers into the single values of an HTTP API
+// response. Set-Cookie values are returned as cookies and repeated values are
+// combined with commas, except for singleton fields which cannot be
+// combined: only their first value is kept and the fields whose other values
+// differ from it are returned so that the caller can log them.
+func headersV2(header http.Header) (headers map[string]string, cookies []string, dropped []string) {
+	headers = make(map[string]string, len(header))
+	cookies = make([]string, 0)
+
+	for headerKey, headerValue := range header {
+		switch {
+		case strings.EqualFold("set-cookie", headerKey):
+			cookies = append(cookies, headerValue...)
+		case len(headerValue) <= 1 || !singletonResponseHeaders[textproto.CanonicalMIMEHeaderKey(headerKey)]:
+			headers[headerKey] = strings.Join(headerValue, ",")
+		default:
+			headers[headerKey] = headerValue[0]
+			for _, value := range headerValue[1:] {
+				if value != headerValue[0] {
+					dropped = append(dropped, headerKey)
+					break
+				}
+			}
+		}
+	}
+	return headers, cookies, dropped
+}
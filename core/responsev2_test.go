@@ -44,9 +44,9 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			Expect(http.StatusOK).To(Equal(response.status))
 		})
 
-		It("Forces the status to a new code", func() {
+		It("Keeps the status of the written response", func() {
 			response.WriteHeader(http.StatusAccepted)
-			Expect(http.StatusAccepted).To(Equal(response.status))
+			Expect(http.StatusOK).To(Equal(response.status))
 		})
 	})
 
@@ -62,7 +62,7 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			Expect("application/json").To(Equal(resp.Header().Get("Content-Type")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.Headers)))
+			Expect(2).To(Equal(len(proxyResp.Headers)))
 			Expect("application/json").To(Equal(proxyResp.Headers["Content-Type"]))
 			Expect(xmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -75,7 +75,7 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			Expect(true).To(Equal(strings.HasPrefix(resp.Header().Get("Content-Type"), "text/xml;")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.Headers)))
+			Expect(2).To(Equal(len(proxyResp.Headers)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResp.Headers["Content-Type"], "text/xml;")))
 			Expect(xmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -88,7 +88,7 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			Expect(true).To(Equal(strings.HasPrefix(resp.Header().Get("Content-Type"), "text/html;")))
 			proxyResp, err := resp.GetProxyResponse()
 			Expect(err).To(BeNil())
-			Expect(1).To(Equal(len(proxyResp.Headers)))
+			Expect(2).To(Equal(len(proxyResp.Headers)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResp.Headers["Content-Type"], "text/html;")))
 			Expect(htmlBodyContent).To(Equal(proxyResp.Body))
 		})
@@ -101,7 +101,7 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 		It("Refuses empty responses with default status code", func() {
 			_, err := emtpyResponse.GetProxyResponse()
 			Expect(err).ToNot(BeNil())
-			Expect("Status code not set on response").To(Equal(err.Error()))
+			Expect(err).To(MatchError(ErrStatusNotSet))
 		})
 
 		simpleResponse := NewProxyResponseWriterV2()
@@ -114,7 +114,7 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 
 			Expect("hello").To(Equal(proxyResponse.Body))
 			Expect(http.StatusOK).To(Equal(proxyResponse.StatusCode))
-			Expect(1).To(Equal(len(proxyResponse.Headers)))
+			Expect(2).To(Equal(len(proxyResponse.Headers)))
 			Expect(true).To(Equal(strings.HasPrefix(proxyResponse.Headers["Content-Type"], "text/plain")))
 			Expect(proxyResponse.IsBase64Encoded).To(BeFalse())
 		})
@@ -126,8 +126,8 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 		if err != nil {
 			Fail("Could not generate random binary body")
 		}
-		binaryResponse.Write(binaryBody)
 		binaryResponse.WriteHeader(http.StatusAccepted)
+		binaryResponse.Write(binaryBody)
 
 		It("Encodes binary responses correctly", func() {
 			proxyResponse, err := binaryResponse.GetProxyResponse()
@@ -138,7 +138,7 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			Expect(base64.StdEncoding.EncodedLen(len(binaryBody))).To(Equal(len(proxyResponse.Body)))
 
 			Expect(base64.StdEncoding.EncodeToString(binaryBody)).To(Equal(proxyResponse.Body))
-			Expect(1).To(Equal(len(proxyResponse.Headers)))
+			Expect(2).To(Equal(len(proxyResponse.Headers)))
 			Expect("application/octet-stream").To(Equal(proxyResponse.Headers["Content-Type"]))
 			Expect(http.StatusAccepted).To(Equal(proxyResponse.StatusCode))
 		})
@@ -153,7 +153,7 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			proxyResponse, err := response.GetProxyResponse()
 			Expect(err).To(BeNil())
 
-			Expect(1).To(Equal(len(proxyResponse.Headers)))
+			Expect(2).To(Equal(len(proxyResponse.Headers)))
 			Expect("application/json").To(Equal(proxyResponse.Headers["Content-Type"]))
 		})
 
@@ -165,10 +165,53 @@ var _ = Describe("ResponseWriterV2 tests", func() {
 			proxyResponse, err := response.GetProxyResponse()
 			Expect(err).To(BeNil())
 
-			Expect(2).To(Equal(len(proxyResponse.Headers)))
+			Expect(3).To(Equal(len(proxyResponse.Headers)))
 			Expect("foobar,barfoo").To(Equal(proxyResponse.Headers["Accepts"]))
 		})
 
+		It("Combines repeated Allow headers", func() {
+			response := NewProxyResponseWriterV2()
+			response.Header().Add("Allow", "GET")
+			response.Header().Add("Allow", "POST")
+			response.WriteHeader(http.StatusMethodNotAllowed)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect("GET,POST").To(Equal(proxyResponse.Headers["Allow"]))
+		})
+
+		It("Combines repeated list and unknown headers", func() {
+			response := NewProxyResponseWriterV2()
+			response.Header().Add("WWW-Authenticate", `Basic realm="api", charset="UTF-8"`)
+			response.Header().Add("WWW-Authenticate", `Bearer realm="api"`)
+			response.Header().Add("Link", `</style.css>; rel=preload; as=style`)
+			response.Header().Add("Link", `</a,b.js>; rel=preload; as=script`)
+			response.Header().Add("X-Custom", "1, 2")
+			response.Header().Add("X-Custom", "3")
+			response.WriteHeader(http.StatusUnauthorized)
+			response.Write([]byte("hello"))
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect(`Basic realm="api", charset="UTF-8",Bearer realm="api"`).To(Equal(proxyResponse.Headers["Www-Authenticate"]))
+			Expect(`</style.css>; rel=preload; as=style,</a,b.js>; rel=preload; as=script`).To(Equal(proxyResponse.Headers["Link"]))
+			Expect("1, 2,3").To(Equal(proxyResponse.Headers["X-Custom"]))
+		})
+
+		It("Does not combine singleton headers", func() {
+			response := NewProxyResponseWriterV2()
+			response.Header().Add("Location", "/first")
+			response.Header().Add("Location", "/second")
+			response.Header().Add("Content-Type", "text/plain")
+			response.Header().Add("Content-Type", "text/plain")
+			response.WriteHeader(http.StatusFound)
+			proxyResponse, err := response.GetProxyResponse()
+			Expect(err).To(BeNil())
+
+			Expect("/first").To(Equal(proxyResponse.Headers["Location"]))
+			Expect("text/plain").To(Equal(proxyResponse.Headers["Content-Type"]))
+		})
+
 		It("Writes cookies correctly", func() {
 			response := NewProxyResponseWriterV2()
 			response.Header().Add("Set-Cookie", "csrftoken=foobar")
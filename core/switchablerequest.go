@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"errors"
 	"github.com/aws/aws-lambda-go/events"
 )
 
@@ -44,7 +43,7 @@ func (s *SwitchableAPIGatewayRequest) UnmarshalJSON(b []byte) error {
 	} else if !v1test && v2test {
 		s.v = &events.APIGatewayV2HTTPRequest{}
 	} else {
-		return errors.New("unable to determine request version")
+		return ErrUnknownVersion
 	}
 	return json.Unmarshal(b, s.v)
 }
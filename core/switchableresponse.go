@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"errors"
 	"github.com/aws/aws-lambda-go/events"
 )
 
@@ -49,7 +48,7 @@ func (s *SwitchableAPIGatewayResponse) UnmarshalJSON(b []byte) error {
 	} else if test && v2test {
 		s.v = &events.APIGatewayV2HTTPResponse{}
 	} else {
-		return errors.New("unable to determine response version")
+		return ErrUnknownVersion
 	}
 	return json.Unmarshal(b, s.v)
 }
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TimeoutError is passed to the error mapper when the handler does not
+// complete before the deadline of the request context. The adapters answer it
+// with a 504 Gateway Timeout response.
+type TimeoutError struct {
+	// Route is the method and path of the request, such as "GET /orders".
+	Route string
+	// Err is the error of the request context, such as
+	// context.DeadlineExceeded.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("handler serving %s did not complete before the invocation deadline: %v", e.Route, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ServeWithTimeout serves req with handler, racing it against the deadline of
+// the request context. If the handler completes in time its response is
+// written to w. Otherwise the handler is abandoned: any later write it makes
+// fails with http.ErrHandlerTimeout and never reaches w. The *TimeoutError is
+// logged, with the logger of w if it is a response writer of an accessor, and
+// answered with the 504 Gateway Timeout response of ProblemDetailsMapper. The
+// Serve method of the accessors answers it with their error mapper instead.
+// The response writers of this package receive the body as the handler writes
+// it, so that their size limits apply, and are reset if the handler is
+// abandoned. Responses to other writers are buffered until the handler
+// completes.
+// Requests without a deadline are served directly.
+// Panics of the handler are propagated to the caller.
+func ServeWithTimeout(handler http.Handler, w http.ResponseWriter, req *http.Request) {
+	logger := slog.Default()
+	if rl, ok := w.(interface {
+		RequestLogger(req *http.Request) *slog.Logger
+	}); ok {
+		logger = rl.RequestLogger(req)
+	}
+
+	err := serveWithTimeout(logger, handler, w, req)
+	var p *PanicError
+	if errors.As(err, &p) {
+		panic(p.Value)
+	}
+	if err != nil {
+		logger.Error("Handler did not complete before the invocation deadline", "error", err)
+		ProblemDetailsMapper{}.MapError(req.Context(), w, err)
+	}
+}
+
+// serveWithTimeout implements ServeWithTimeout. Panics of the handler are
+// recovered and returned as a *PanicError with the stack of the goroutine that
+// panicked, and handlers that do not complete in time are abandoned and
+// reported with a *TimeoutError, leaving the response to the caller.
+// Superfluous WriteHeader calls are logged with logger.
+func serveWithTimeout(logger *slog.Logger, handler http.Handler, w http.ResponseWriter, req *http.Request) (err error) {
+	ctx := req.Context()
+	if _, ok := ctx.Deadline(); !ok {
+		defer func() {
+			if v := recover(); v != nil {
+				err = newPanicError(req, v)
+			}
+		}()
+		handler.ServeHTTP(w, req)
+		return nil
+	}
+
+	tw := &timeoutWriter{
+		headers: make(http.Header),
+		ctx:     ctx,
+		logger:  logger,
+	}
+	if dst, ok := w.(resettableWriter); ok {
+		tw.dst = dst
+	}
+	done := make(chan struct{})
+	panicChan := make(chan *PanicError, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				panicChan <- newPanicError(req, v)
+			}
+		}()
+		handler.ServeHTTP(tw, req)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicChan:
+		return p
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		copyHeaders(w.Header(), tw.headers)
+		if tw.dst != nil {
+			return nil
+		}
+		if tw.status != 0 {
+			w.WriteHeader(tw.status)
+		}
+		if tw.wroteBody {
+			w.Write(tw.body.Bytes())
+		}
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		tw.timedOut = true
+		if tw.dst != nil {
+			tw.dst.resetResponse()
+		}
+		return &TimeoutError{Route: req.Method + " " + req.URL.Path, Err: ctx.Err()}
+	}
+	return nil
+}
+
+// resettableWriter is implemented by the response writers of this package,
+// which buffer the response until it is returned and can discard it.
+type resettableWriter interface {
+	http.ResponseWriter
+	resetResponse()
+}
+
+// timeoutWriter guards the response writer of a handler served by
+// ServeWithTimeout so that the response of the handler is discarded if it is
+// abandoned. The headers are kept apart from the response writer, which the
+// handler could otherwise modify once abandoned, and copied to it when the
+// response is written. The status and body are forwarded to dst if the
+// response writer is resettable and buffered otherwise.
+type timeoutWriter struct {
+	mu        sync.Mutex
+	headers   http.Header
+	dst       resettableWriter
+	body      bytes.Buffer
+	status    int
+	wroteBody bool
+	timedOut  bool
+	ctx       context.Context
+	deadline  time.Time
+	logger    *slog.Logger
+}
+
+// Header implementation from the http.ResponseWriter interface.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.headers
+}
+
+// Write writes the response body to the response writer or buffers it. Once
+// the handler has been abandoned it returns http.ErrHandlerTimeout, and once
+// the deadline set with SetWriteDeadline has passed os.ErrDeadlineExceeded.
+func (tw *timeoutWriter) Write(body []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if err := tw.writeErr(); err != nil {
+		return 0, err
+	}
+	if tw.dst != nil {
+		copyHeaders(tw.dst.Header(), tw.headers)
+		return tw.dst.Write(body)
+	}
+	tw.wroteBody = true
+	return tw.body.Write(body)
+}
+
+// WriteString implements the io.StringWriter interface. It behaves like Write
+// without converting s to a byte slice.
+func (tw *timeoutWriter) WriteString(s string) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if err := tw.writeErr(); err != nil {
+		return 0, err
+	}
+	if tw.dst != nil {
+		copyHeaders(tw.dst.Header(), tw.headers)
+		return io.WriteString(tw.dst, s)
+	}
+	tw.wroteBody = true
+	return tw.body.WriteString(s)
+}
+
+// ReadFrom implements the io.ReaderFrom interface used by io.Copy to copy
+// bodies into the response.
+func (tw *timeoutWriter) ReadFrom(src io.Reader) (int64, error) {
+	return copyBody(tw, src)
+}
+
+// writeErr returns the error of a write to the response, and records the
+// default status of the response otherwise. It must be called with tw.mu held.
+func (tw *timeoutWriter) writeErr() error {
+	if tw.timedOut {
+		return http.ErrHandlerTimeout
+	}
+	if deadlineExceeded(tw.deadline) {
+		return os.ErrDeadlineExceeded
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	if !bodyAllowedForStatus(tw.status) {
+		return http.ErrBodyNotAllowed
+	}
+	return nil
+}
+
+// WriteHeader records the status code of the response. Like the response
+// writers it keeps the first final status and ignores informational ones.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if tw.status != 0 {
+		logSuperfluousWriteHeader(tw.logger, tw.status, status)
+		return
+	}
+	if isInformational(status) {
+		return
+	}
+	tw.status = status
+	if tw.dst != nil {
+		copyHeaders(tw.dst.Header(), tw.headers)
+		tw.dst.WriteHeader(status)
+	}
+}
+
+// copyHeaders sets the headers of dst to the values of the headers in src.
+func copyHeaders(dst, src http.Header) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// Flush implements the Flusher interface. The response is buffered until
+// it is returned so this is intentionally a no-op.
+func (tw *timeoutWriter) Flush() {
+	//no-op
+}
+
+// SetWriteDeadline sets the deadline after which writes to the response fail
+// with os.ErrDeadlineExceeded. It is used by http.ResponseController, which
+// finds the methods of the writer directly: unwrapping it would let the
+// handler write to the response after it has been abandoned.
+func (tw *timeoutWriter) SetWriteDeadline(deadline time.Time) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.deadline = deadline
+	return nil
+}
+
+// CloseNotify implements the http.CloseNotifier interface. The returned
+// channel receives a value once the request context is done.
+//
+// Deprecated: handlers should use the request context instead.
+func (tw *timeoutWriter) CloseNotify() <-chan bool {
+	ch := make(chan bool, 1)
+	context.AfterFunc(tw.ctx, func() {
+		ch <- true
+	})
+	return ch
+}
@@ -0,0 +1,217 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServeWithTimeout tests", func() {
+	const timeoutBody = `{"type":"about:blank","title":"Gateway Timeout","status":504}`
+	var release chan struct{}
+	var writeErr chan error
+
+	stuckHandler := func() http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			<-release
+			_, err := w.Write([]byte("too late"))
+			writeErr <- err
+		})
+	}
+
+	timeoutRequest := func() (*http.Request, context.CancelFunc) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/slow", nil)
+		Expect(err).To(BeNil())
+		return req, cancel
+	}
+
+	BeforeEach(func() {
+		release = make(chan struct{})
+		writeErr = make(chan error, 1)
+	})
+
+	It("Copies the response of a handler that completes in time", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		w := core.NewProxyResponseWriter()
+		core.ServeWithTimeout(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("hello"))
+		}), w, req)
+
+		resp, err := w.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(resp.Body).To(Equal("hello"))
+		Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"text/plain"}))
+	})
+
+	It("Serves requests without a deadline directly", func() {
+		req, err := http.NewRequest(http.MethodGet, "/hello", nil)
+		Expect(err).To(BeNil())
+
+		w := core.NewProxyResponseWriter()
+		core.ServeWithTimeout(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			Expect(rw).To(BeIdenticalTo(w))
+			rw.WriteHeader(http.StatusNoContent)
+		}), w, req)
+
+		resp, err := w.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+	})
+
+	It("Returns a timeout response for API Gateway proxy events", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		w := core.NewProxyResponseWriter()
+		core.ServeWithTimeout(stuckHandler(), w, req)
+
+		resp, err := w.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+		Expect(resp.Body).To(Equal(timeoutBody))
+		Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+
+		close(release)
+		Expect(<-writeErr).To(Equal(http.ErrHandlerTimeout))
+		resp, err = w.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect(resp.Body).To(Equal(timeoutBody))
+	})
+
+	It("Discards the partial response of an abandoned handler", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		w := core.NewProxyResponseWriter()
+		core.ServeWithTimeout(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Partial", "1")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("partial"))
+			<-release
+			_, err := w.Write([]byte("too late"))
+			writeErr <- err
+		}), w, req)
+
+		resp, err := w.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+		Expect(resp.MultiValueHeaders).ToNot(HaveKey("X-Partial"))
+		Expect(resp.Body).To(Equal(timeoutBody))
+
+		close(release)
+		Expect(<-writeErr).To(Equal(http.ErrHandlerTimeout))
+	})
+
+	It("Buffers the response of a handler for other response writers", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		rec := httptest.NewRecorder()
+		core.ServeWithTimeout(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("hello"))
+		}), rec, req)
+
+		Expect(rec.Code).To(Equal(http.StatusAccepted))
+		Expect(rec.Body.String()).To(Equal("hello"))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("text/plain"))
+	})
+
+	It("Returns a timeout response for API Gateway V2 events", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		w := core.NewProxyResponseWriterV2()
+		core.ServeWithTimeout(stuckHandler(), w, req)
+
+		resp, err := w.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+		Expect(resp.Headers["Content-Type"]).To(Equal("application/problem+json"))
+		Expect(resp.Body).To(Equal(timeoutBody))
+
+		close(release)
+		Expect(<-writeErr).To(Equal(http.ErrHandlerTimeout))
+	})
+
+	It("Returns a timeout response for ALB events", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		w := core.NewProxyResponseWriterALB()
+		core.ServeWithTimeout(stuckHandler(), w, req)
+
+		resp, err := w.GetProxyResponse()
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+		Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+		Expect(resp.Body).To(Equal(timeoutBody))
+
+		close(release)
+		Expect(<-writeErr).To(Equal(http.ErrHandlerTimeout))
+	})
+
+	It("Reports timeouts to the error mapper and logger of the accessor", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		var logs bytes.Buffer
+		var mapped error
+		accessor := core.NewRequestAccessor(
+			core.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+			core.WithErrorMapper(core.ErrorMapperFunc(func(ctx context.Context, w http.ResponseWriter, err error) {
+				mapped = err
+				w.WriteHeader(core.ErrorStatusCode(err))
+				w.Write([]byte("too slow"))
+			})),
+		)
+		w := accessor.NewProxyResponseWriter()
+		defer w.Release()
+		err := accessor.Serve(stuckHandler(), w, req)
+		var timeoutErr *core.TimeoutError
+		Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+		Expect(timeoutErr.Route).To(Equal("GET /slow"))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		Expect(logs.String()).To(BeEmpty())
+
+		resp, respErr := accessor.ErrorResponse(context.Background(), getProxyRequest("/slow", "GET"), err)
+		Expect(respErr).To(BeNil())
+		Expect(mapped).To(Equal(err))
+		Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+		Expect(resp.Body).To(Equal("too slow"))
+		Expect(strings.Count(logs.String(), "\n")).To(Equal(1))
+		Expect(logs.String()).To(ContainSubstring(`level=ERROR msg="Handler did not complete before the invocation deadline"`))
+
+		close(release)
+		Expect(<-writeErr).To(Equal(http.ErrHandlerTimeout))
+	})
+
+	It("Propagates handler panics to the caller", func() {
+		req, cancel := timeoutRequest()
+		defer cancel()
+
+		w := core.NewProxyResponseWriter()
+		Expect(func() {
+			core.ServeWithTimeout(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				panic("boom")
+			}), w, req)
+		}).To(PanicWith("boom"))
+	})
+})
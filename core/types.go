@@ -1,7 +1,9 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -12,9 +14,33 @@ func GatewayTimeout() events.APIGatewayProxyResponse {
 	return events.APIGatewayProxyResponse{StatusCode: http.StatusGatewayTimeout}
 }
 
-// NewLoggedError generates a new error and logs it to stdout
+// ErrorResponse returns the response to an error of an adapter, written by
+// the error mapper set with WithErrorMapper to a response writer of the
+// accessor. ctx is the context of the Lambda invocation and event the event
+// being proxied, whose route key is logged with the error. The error is
+// logged rather than returned so that the client receives the response; an
+// error is only returned if the mapper writes an invalid response.
+func (r *RequestAccessor) ErrorResponse(ctx context.Context, event events.APIGatewayProxyRequest, err error) (events.APIGatewayProxyResponse, error) {
+	w := r.NewProxyResponseWriter()
+	defer w.Release()
+	r.writeError(ctx, eventTypeAPIGateway, routeKeyV1(event), w, err)
+
+	resp, respErr := w.GetProxyResponse()
+	if respErr != nil {
+		r.eventLogger(ctx, eventTypeAPIGateway, routeKeyV1(event)).Error("Error while generating error response", "error", respErr)
+		return GatewayTimeout(), fmt.Errorf("Error while generating error response: %w", respErr)
+	}
+	return resp, nil
+}
+
+// NewLoggedError generates a new error and logs it with the default slog
+// logger
+//
+// Deprecated: the adapters report their errors through ErrorResponse, which
+// logs them with the logger set with WithLogger. Create errors with
+// fmt.Errorf and log them with the logger returned by Logger instead.
 func NewLoggedError(format string, a ...interface{}) error {
 	err := fmt.Errorf(format, a...)
-	fmt.Println(err.Error())
+	slog.Default().Error(err.Error())
 	return err
 }
@@ -1,11 +1,39 @@
 package core
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 )
 
 func GatewayTimeoutALB() events.ALBTargetGroupResponse {
-	return events.ALBTargetGroupResponse{StatusCode: http.StatusGatewayTimeout}
+	return events.ALBTargetGroupResponse{
+		StatusCode:        http.StatusGatewayTimeout,
+		StatusDescription: statusDescriptionALB(http.StatusGatewayTimeout),
+	}
+}
+
+// ErrorResponse returns the response to an error of an adapter, written by
+// the error mapper set with WithErrorMapper to a response writer of the
+// accessor. ctx is the context of the Lambda invocation and event the event
+// being proxied, whose route key is logged with the error. As with
+// ProxyResponseWriterALB, the headers are returned in the MultiValueHeaders
+// field when its target group has multi-value headers enabled, and in the
+// Headers field otherwise. The error is logged rather than returned so that
+// the client receives the response; an error is only returned if the mapper
+// writes an invalid response.
+func (r *RequestAccessorALB) ErrorResponse(ctx context.Context, event events.ALBTargetGroupRequest, err error) (events.ALBTargetGroupResponse, error) {
+	w := r.NewProxyResponseWriterALB()
+	defer w.Release()
+	w.singleValueHeaders = !isMultiValueALB(event)
+	r.writeError(ctx, eventTypeALB, routeKeyALB(event), w, err)
+
+	resp, respErr := w.GetProxyResponse()
+	if respErr != nil {
+		r.eventLogger(ctx, eventTypeALB, routeKeyALB(event)).Error("Error while generating error response", "error", respErr)
+		return GatewayTimeoutALB(), fmt.Errorf("Error while generating error response: %w", respErr)
+	}
+	return resp, nil
 }
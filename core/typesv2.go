@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -9,3 +11,22 @@ import (
 func GatewayTimeoutV2() events.APIGatewayV2HTTPResponse {
 	return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusGatewayTimeout}
 }
+
+// ErrorResponse returns the response to an error of an adapter, written by
+// the error mapper set with WithErrorMapper to a response writer of the
+// accessor. ctx is the context of the Lambda invocation and event the event
+// being proxied, whose route key is logged with the error. The error is
+// logged rather than returned so that the client receives the response; an
+// error is only returned if the mapper writes an invalid response.
+func (r *RequestAccessorV2) ErrorResponse(ctx context.Context, event events.APIGatewayV2HTTPRequest, err error) (events.APIGatewayV2HTTPResponse, error) {
+	w := r.NewProxyResponseWriterV2()
+	defer w.Release()
+	r.writeError(ctx, eventTypeAPIGatewayV2, event.RouteKey, w, err)
+
+	resp, respErr := w.GetProxyResponse()
+	if respErr != nil {
+		r.eventLogger(ctx, eventTypeAPIGatewayV2, event.RouteKey).Error("Error while generating error response", "error", respErr)
+		return GatewayTimeoutV2(), fmt.Errorf("Error while generating error response: %w", respErr)
+	}
+	return resp, nil
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// InvalidEventError is returned by the accessors when a proxy event is
+// malformed and cannot be converted into an http.Request, for example because
+// of an invalid method, an unparsable path or a badly encoded body. It
+// describes a mistake of the client rather than a fault of the function.
+type InvalidEventError struct {
+	// Field is the name of the invalid event field, such as httpMethod.
+	Field string
+	// Err is the reason the field is invalid.
+	Err error
+}
+
+func (e *InvalidEventError) Error() string {
+	return fmt.Sprintf("invalid %s in event: %v", e.Field, e.Err)
+}
+
+func (e *InvalidEventError) Unwrap() error {
+	return e.Err
+}
+
+// IsInvalidEvent reports whether err, or an error it wraps, is an
+// InvalidEventError.
+func IsInvalidEvent(err error) bool {
+	var invalid *InvalidEventError
+	return errors.As(err, &invalid)
+}
+
+// validateMethod checks that method is a token as defined in RFC 9110
+// section 5.6.2. An empty method is accepted and, as with http.NewRequest,
+// means GET.
+func validateMethod(field, method string) error {
+	if i := strings.IndexFunc(method, func(c rune) bool { return !isTokenChar(c) }); i >= 0 {
+		return &InvalidEventError{Field: field, Err: fmt.Errorf("method %q contains an invalid character", method)}
+	}
+	return nil
+}
+
+func isTokenChar(c rune) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+}
+
+// newRequestError classifies an error returned by http.NewRequest. Errors
+// caused by an invalid custom host are configuration faults and returned as
+// is, all others are caused by the path of the event.
+func newRequestError(serverAddress string, err error) error {
+	if _, hostErr := url.Parse(serverAddress); hostErr != nil {
+		return err
+	}
+	return &InvalidEventError{Field: "path", Err: err}
+}
@@ -0,0 +1,99 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event validation tests", func() {
+	Context("Invalid events", func() {
+		It("Rejects invalid methods", func() {
+			for _, method := range []string{"GE T", "GET\n", "PÖST", "(GET)"} {
+				_, err := core.NewRequestAccessor().EventToRequest(getProxyRequest("/orders", method))
+				Expect(core.IsInvalidEvent(err)).To(BeTrue(), method)
+
+				var invalid *core.InvalidEventError
+				Expect(errors.As(err, &invalid)).To(BeTrue())
+				Expect(invalid.Field).To(Equal("httpMethod"))
+
+				_, err = core.NewRequestAccessorV2().EventToRequest(getProxyRequestV2("/orders", method))
+				Expect(core.IsInvalidEvent(err)).To(BeTrue(), method)
+
+				req := getALBProxyRequest("/orders", method, getALBRequestContext(), false, nil, "", nil, nil, nil)
+				_, err = core.NewRequestAccessorALB().EventToRequest(req)
+				Expect(core.IsInvalidEvent(err)).To(BeTrue(), method)
+			}
+		})
+
+		It("Accepts extension methods", func() {
+			httpReq, err := core.NewRequestAccessor().EventToRequest(getProxyRequest("/orders", "propfind"))
+			Expect(err).To(BeNil())
+			Expect(httpReq.Method).To(Equal("PROPFIND"))
+		})
+
+		It("Rejects badly encoded bodies", func() {
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "not base64!"
+			req.IsBase64Encoded = true
+
+			_, err := core.NewRequestAccessor().EventToRequest(req)
+			var invalid *core.InvalidEventError
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(invalid.Field).To(Equal("body"))
+			Expect(errors.Unwrap(err)).ToNot(BeNil())
+		})
+
+		It("Answers bodies with invalid base64 characters with a bad request", func() {
+			ctx := context.Background()
+
+			req := getProxyRequest("/orders", "POST")
+			req.Body = "!!!!"
+			req.IsBase64Encoded = true
+			accessor := core.NewRequestAccessor()
+			_, err := accessor.EventToRequestWithContext(ctx, req)
+			Expect(core.IsInvalidEvent(err)).To(BeTrue())
+			resp, err := accessor.ErrorResponse(ctx, req, err)
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(400))
+
+			reqV2 := getProxyRequestV2("/orders", "POST")
+			reqV2.Body = "!!!!"
+			reqV2.IsBase64Encoded = true
+			accessorV2 := core.NewRequestAccessorV2()
+			_, err = accessorV2.EventToRequestWithContext(ctx, reqV2)
+			Expect(core.IsInvalidEvent(err)).To(BeTrue())
+			respV2, err := accessorV2.ErrorResponse(ctx, reqV2, err)
+			Expect(err).To(BeNil())
+			Expect(respV2.StatusCode).To(Equal(400))
+
+			reqALB := getALBProxyRequest("/orders", "POST", getALBRequestContext(), true, nil, "!!!!", nil, nil, nil)
+			accessorALB := core.NewRequestAccessorALB()
+			_, err = accessorALB.EventToRequestWithContext(ctx, reqALB)
+			Expect(core.IsInvalidEvent(err)).To(BeTrue())
+			respALB, err := accessorALB.ErrorResponse(ctx, reqALB, err)
+			Expect(err).To(BeNil())
+			Expect(respALB.StatusCode).To(Equal(400))
+		})
+
+		It("Rejects unparsable paths", func() {
+			_, err := core.NewRequestAccessor().EventToRequest(getProxyRequest("/orders/%zz", "GET"))
+			var invalid *core.InvalidEventError
+			Expect(errors.As(err, &invalid)).To(BeTrue())
+			Expect(invalid.Field).To(Equal("path"))
+		})
+	})
+
+	Context("Configuration faults", func() {
+		It("Does not blame the event for an invalid custom host", func() {
+			accessor := core.NewRequestAccessor(core.WithCustomHost("http://[::1"))
+			_, err := accessor.EventToRequest(getProxyRequest("/orders", "GET"))
+			Expect(err).ToNot(BeNil())
+			Expect(core.IsInvalidEvent(err)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,359 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// responseWriter implements the http.ResponseWriter shared by the proxy
+// response writers, which embed it and only differ in the proxy response they
+// build from the buffered response.
+type responseWriter struct {
+	responseOptions
+
+	headers         http.Header
+	headersReturned bool
+	body            bytes.Buffer
+	status          int
+	ctx             context.Context
+	cancel          context.CancelFunc
+	basePath        string
+	acceptEncoding  []string
+	invocationCtx   context.Context
+	request         string
+	method          string
+	headBodySize    int
+	overflowed      bool
+	writeDeadline   time.Time
+}
+
+// prepare readies a new or released writer for a response, with an empty map
+// of headers and a status code of -1.
+func (r *responseWriter) prepare() {
+	if r.headers == nil {
+		r.headers = make(http.Header)
+	}
+	r.status = defaultStatusCode
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+}
+
+// release resets the writer so that it can be pooled, keeping its body buffer
+// unless it grew too large and its map of headers unless it was returned in a
+// proxy response. It reports whether the writer was in use: released writers
+// must not be pooled twice.
+func (r *responseWriter) release() bool {
+	if r.cancel == nil {
+		return false
+	}
+	r.cancel()
+	body := r.body
+	headers := r.headers
+	if r.headersReturned {
+		headers = nil
+	}
+	clear(headers)
+	*r = responseWriter{headers: headers}
+	if body.Cap() <= maxPooledBodySize {
+		body.Reset()
+		r.body = body
+	}
+	return true
+}
+
+// resetResponse discards the status, headers and body written to the writer,
+// such as the partial response of a handler abandoned by ServeWithTimeout.
+func (r *responseWriter) resetResponse() {
+	clear(r.headers)
+	r.body.Reset()
+	r.status = defaultStatusCode
+	r.headBodySize = 0
+	r.overflowed = false
+}
+
+// BindRequest derives the context of req from its Lambda invocation context,
+// with a deadline set reserve before the function timeout, and ties the
+// context to the response writer. The context is cancelled once
+// GetProxyResponse produces the response.
+// It returns a copy of req that should be served to the handler.
+func (r *responseWriter) BindRequest(req *http.Request, reserve time.Duration) *http.Request {
+	r.cancel()
+	r.ctx, r.cancel = NewInvocationContext(req.Context(), reserve)
+	r.basePath, _ = GetBasePathFromContext(req.Context())
+	r.acceptEncoding = req.Header.Values("Accept-Encoding")
+	r.invocationCtx = req.Context()
+	r.request = req.Method + " " + req.URL.Path
+	r.method = req.Method
+	return req.WithContext(r.ctx)
+}
+
+// CloseNotify implements the http.CloseNotifier interface. The returned
+// channel receives a value once the request context bound to the writer
+// is done.
+//
+// Deprecated: handlers should use the request context instead.
+func (r *responseWriter) CloseNotify() <-chan bool {
+	ch := make(chan bool, 1)
+	context.AfterFunc(r.ctx, func() {
+		ch <- true
+	})
+	return ch
+}
+
+// Header implementation from the http.ResponseWriter interface.
+func (r *responseWriter) Header() http.Header {
+	return r.headers
+}
+
+// Write sets the response body in the object. If no status code
+// was set before with the WriteHeader method it sets the status
+// for the response to 200 OK. Once the deadline set with SetWriteDeadline
+// has passed it returns os.ErrDeadlineExceeded. Statuses that do not allow
+// a body, such as 204 No Content, make it return http.ErrBodyNotAllowed, and
+// the body of a response to a HEAD request is only counted for its
+// Content-Length.
+func (r *responseWriter) Write(body []byte) (int, error) {
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+
+	// if the content type header is not set when we write the body we try to
+	// detect one and set it by default, unless detection is disabled
+	r.setDefaultContentType(r.Header(), body)
+
+	// the body of a response to a HEAD request is only measured
+	if r.method == http.MethodHead {
+		r.headBodySize += len(body)
+		return len(body), nil
+	}
+
+	// bodies that can no longer be returned are not buffered
+	if r.overflowed || r.discardBody(r.headers, r.body.Len()+len(body), body) {
+		r.overflowed = true
+		r.body.Reset()
+		return len(body), nil
+	}
+
+	return (&r.body).Write(body)
+}
+
+// WriteString implements the io.StringWriter interface. It behaves like Write
+// without converting s to a byte slice.
+func (r *responseWriter) WriteString(s string) (int, error) {
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+
+	r.setDefaultContentType(r.Header(), sniffPrefix(s))
+
+	if r.method == http.MethodHead {
+		r.headBodySize += len(s)
+		return len(s), nil
+	}
+
+	if r.overflowed || r.discardBody(r.headers, r.body.Len()+len(s), stringBytes(s)) {
+		r.overflowed = true
+		r.body.Reset()
+		return len(s), nil
+	}
+
+	return (&r.body).WriteString(s)
+}
+
+// writeErr returns the error of a write to the response, and sets the
+// default status of the response otherwise.
+func (r *responseWriter) writeErr() error {
+	if deadlineExceeded(r.writeDeadline) {
+		return os.ErrDeadlineExceeded
+	}
+	if r.status == defaultStatusCode {
+		r.status = http.StatusOK
+	}
+	if !bodyAllowedForStatus(r.status) {
+		return http.ErrBodyNotAllowed
+	}
+	return nil
+}
+
+// ReadFrom implements the io.ReaderFrom interface used by io.Copy to copy
+// bodies into the response.
+func (r *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	return copyBody(r, src)
+}
+
+// WriteHeader sets the status code of the response. As with net/http only
+// the first final status is kept: later calls are logged and ignored.
+// Informational 1xx statuses cannot be sent ahead of the proxy response and
+// are ignored. Status codes outside 100-599 make GetProxyResponse fail.
+func (r *responseWriter) WriteHeader(status int) {
+	if r.status != defaultStatusCode {
+		logSuperfluousWriteHeader(r.contextLogger(r.invocationCtx), r.status, status)
+		return
+	}
+	if isInformational(status) {
+		return
+	}
+	r.status = status
+}
+
+// Flush implements the http.Flusher interface. The response is buffered
+// until GetProxyResponse is called so this is intentionally a no-op.
+func (r *responseWriter) Flush() {
+	//no-op
+}
+
+// SetWriteDeadline sets the deadline after which writes to the response fail
+// with os.ErrDeadlineExceeded. It is used by http.ResponseController.
+// A zero value means writes will not time out.
+func (r *responseWriter) SetWriteDeadline(deadline time.Time) error {
+	r.writeDeadline = deadline
+	return nil
+}
+
+// proxyResponse cancels the request context and prepares the buffered
+// response to be returned: trailers are merged into the headers, paths are
+// rewritten, the body is compressed and encoded, oversized responses are
+// replaced and Content-Length is set. It returns the encoded body and whether
+// it is base64 encoded, or an error if the status of the response is not set
+// or invalid.
+func (r *responseWriter) proxyResponse() (string, bool, error) {
+	r.cancel()
+
+	if r.status == defaultStatusCode {
+		return "", false, ErrStatusNotSet
+	}
+	if !validStatus(r.status) {
+		return "", false, fmt.Errorf("%w %d on response", ErrInvalidStatusCode, r.status)
+	}
+
+	mergeTrailers(r.headers)
+	rewriteBasePath(r.headers, r.basePath)
+
+	body, compressed := r.compressBody(r.headers, r.status, r.acceptEncoding, (&r.body).Bytes())
+	output, isBase64 := r.encodeBody(r.headers, body, compressed)
+
+	contentLength := len(body)
+	if r.overflowed || r.responseTooLarge(r.headers, output) {
+		if r.overflowed {
+			body = nil
+		}
+		r.status, r.headers, output = r.oversizedResponse(r.invocationCtx, r.request, r.headers, body)
+		contentLength = len(output)
+		isBase64 = false
+	}
+	if r.method == http.MethodHead {
+		contentLength = r.headBodySize
+	}
+	setContentLength(r.headers, r.status, r.method == http.MethodHead, contentLength)
+
+	return output, isBase64, nil
+}
+
+// returnHeaders returns the map of headers of the response to be used in the
+// proxy response, which must then not be reused by the writer once released.
+func (r *responseWriter) returnHeaders() http.Header {
+	r.headersReturned = true
+	return r.headers
+}
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// sniffPrefix returns the part of s used to detect its content type.
+func sniffPrefix(s string) []byte {
+	return []byte(s[:min(len(s), sniffLen)])
+}
+
+// stringBytes returns the bytes of s without copying them. The returned slice
+// must not be modified.
+func stringBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// deadlineExceeded reports whether the write deadline set with
+// SetWriteDeadline has passed. A zero deadline never passes.
+func deadlineExceeded(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// copyBody copies src to w through its Write method. It implements the
+// io.ReaderFrom interface of the response writers, which buffer the body in
+// memory: sources implementing io.WriterTo, such as bytes.Reader and
+// strings.Reader, write themselves in one call and others are copied in
+// chunks.
+func copyBody(w io.Writer, src io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{w}, src)
+}
+
+// mergeTrailers merges the trailers of a response into its headers. Proxy
+// responses have no trailers, but as the body is buffered the values the
+// handler sets after writing it can be sent as headers. The Trailer header
+// declaring them is removed and the names of headers set with
+// http.TrailerPrefix are restored.
+func mergeTrailers(header http.Header) {
+	header.Del("Trailer")
+	for k, values := range header {
+		if !strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		delete(header, k)
+		name := textproto.CanonicalMIMEHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix))
+		if name == "" {
+			continue
+		}
+		header[name] = append(header[name], values...)
+	}
+}
+
+// validStatus reports whether status can be returned in a proxy response.
+func validStatus(status int) bool {
+	return status >= 100 && status <= 599
+}
+
+// maxPooledBodySize is the capacity above which the body buffer of a released
+// response writer is dropped instead of being kept for reuse, so that a few
+// large responses do not hold on to memory.
+const maxPooledBodySize = 1 << 20
+
+// isInformational reports whether status is an informational 1xx status.
+// Proxy responses cannot send them ahead of the final response, so the
+// writers ignore them. 101 Switching Protocols is a final status, as in
+// net/http.
+func isInformational(status int) bool {
+	return status >= 100 && status <= 199 && status != http.StatusSwitchingProtocols
+}
+
+// logSuperfluousWriteHeader logs a WriteHeader call made once the status of
+// the response is set, which has no effect.
+func logSuperfluousWriteHeader(logger *slog.Logger, current, status int) {
+	logger.Warn("http: superfluous response.WriteHeader call", "status", status, "currentStatus", current)
+}
+
+// setContentLength sets the Content-Length header of a response to the
+// length of its body. Responses to HEAD requests keep the length set by the
+// handler, or get the length of the body it wrote if any. Responses whose
+// status does not allow a body have no Content-Length, except 304 Not
+// Modified for which it describes the selected representation.
+func setContentLength(header http.Header, status int, head bool, length int) {
+	switch {
+	case !bodyAllowedForStatus(status):
+		if status != http.StatusNotModified {
+			header.Del("Content-Length")
+		}
+	case head:
+		if header.Get("Content-Length") == "" && length > 0 {
+			header.Set("Content-Length", strconv.Itoa(length))
+		}
+	default:
+		header.Set("Content-Length", strconv.Itoa(length))
+	}
+}
@@ -0,0 +1,251 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// proxyResponseWriter is the part of the response writers shared by the
+// REST API, HTTP API and ALB writers.
+type proxyResponseWriter interface {
+	http.ResponseWriter
+	BindRequest(req *http.Request, reserve time.Duration) *http.Request
+	Release()
+}
+
+var _ = Describe("Response writer interface tests", func() {
+	writers := map[string]func() (proxyResponseWriter, func() (int, http.Header, string)){
+		"REST API": func() (proxyResponseWriter, func() (int, http.Header, string)) {
+			w := core.NewProxyResponseWriter()
+			return w, func() (int, http.Header, string) {
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				return resp.StatusCode, http.Header(resp.MultiValueHeaders), resp.Body
+			}
+		},
+		"HTTP API": func() (proxyResponseWriter, func() (int, http.Header, string)) {
+			w := core.NewProxyResponseWriterV2()
+			return w, func() (int, http.Header, string) {
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				header := make(http.Header)
+				for k, v := range resp.Headers {
+					header.Set(k, v)
+				}
+				return resp.StatusCode, header, resp.Body
+			}
+		},
+		"ALB": func() (proxyResponseWriter, func() (int, http.Header, string)) {
+			w := core.NewProxyResponseWriterALB()
+			return w, func() (int, http.Header, string) {
+				resp, err := w.GetProxyResponse()
+				Expect(err).To(BeNil())
+				return resp.StatusCode, http.Header(resp.MultiValueHeaders), resp.Body
+			}
+		},
+	}
+
+	for name, newWriter := range writers {
+		newWriter := newWriter
+
+		Context(name+" writer", func() {
+			It("Resets released writers for reuse", func() {
+				w, getResponse := newWriter()
+				w.Header().Set("X-Old", "1")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("old"))
+				status, header, body := getResponse()
+				w.Release()
+				w.Release()
+
+				for i := 0; i < 3; i++ {
+					w, getResponse = newWriter()
+					w.Write([]byte("new"))
+					newStatus, newHeader, newBody := getResponse()
+					Expect(newStatus).To(Equal(http.StatusOK))
+					Expect(newHeader.Get("X-Old")).To(BeEmpty())
+					Expect(newBody).To(Equal("new"))
+					w.Release()
+				}
+
+				Expect(status).To(Equal(http.StatusCreated))
+				Expect(header.Get("X-Old")).To(Equal("1"))
+				Expect(body).To(Equal("old"))
+			})
+
+			It("Supports http.ResponseController", func() {
+				w, response := newWriter()
+				rc := http.NewResponseController(w)
+
+				Expect(rc.Flush()).To(BeNil())
+				Expect(rc.SetWriteDeadline(time.Now().Add(time.Minute))).To(BeNil())
+				_, err := w.Write([]byte("hello"))
+				Expect(err).To(BeNil())
+
+				Expect(rc.SetWriteDeadline(time.Now().Add(-time.Second))).To(BeNil())
+				_, err = w.Write([]byte(" world"))
+				Expect(errors.Is(err, os.ErrDeadlineExceeded)).To(BeTrue())
+				_, err = io.WriteString(w, " world")
+				Expect(errors.Is(err, os.ErrDeadlineExceeded)).To(BeTrue())
+
+				Expect(rc.SetWriteDeadline(time.Time{})).To(BeNil())
+				_, err = w.Write([]byte("!"))
+				Expect(err).To(BeNil())
+
+				Expect(rc.EnableFullDuplex()).To(MatchError(http.ErrNotSupported))
+
+				status, _, body := response()
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(body).To(Equal("hello!"))
+			})
+
+			It("Copies bodies with io.WriteString and io.Copy", func() {
+				w, response := newWriter()
+
+				_, err := io.WriteString(w, "<html>")
+				Expect(err).To(BeNil())
+				n, err := io.Copy(w, strings.NewReader("<body>"))
+				Expect(err).To(BeNil())
+				Expect(n).To(Equal(int64(6)))
+				n, err = io.Copy(w, struct{ io.Reader }{bytes.NewBufferString("</body></html>")})
+				Expect(err).To(BeNil())
+				Expect(n).To(Equal(int64(14)))
+
+				status, header, body := response()
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(header.Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+				Expect(body).To(Equal("<html><body></body></html>"))
+			})
+
+			It("Merges trailers into the headers", func() {
+				w, response := newWriter()
+				w.Header().Set("Trailer", "X-Checksum")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("hello"))
+				w.Header().Set("X-Checksum", "5d41402a")
+				w.Header().Set(http.TrailerPrefix+"X-Duration", "12ms")
+
+				_, header, _ := response()
+				Expect(header).ToNot(HaveKey("Trailer"))
+				Expect(header).ToNot(HaveKey("Trailer:X-Duration"))
+				Expect(header.Get("X-Checksum")).To(Equal("5d41402a"))
+				Expect(header.Get("X-Duration")).To(Equal("12ms"))
+			})
+
+			It("Supports http.ResponseController through ServeWithTimeout", func() {
+				w, response := newWriter()
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				req := w.BindRequest(httptest.NewRequest(http.MethodGet, "/hello", nil).WithContext(ctx), time.Second)
+
+				core.ServeWithTimeout(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					rc := http.NewResponseController(w)
+					w.Header().Set("Trailer", "X-Checksum")
+					io.WriteString(w, "hello")
+					Expect(rc.Flush()).To(BeNil())
+					Expect(rc.SetWriteDeadline(time.Now().Add(-time.Second))).To(BeNil())
+					_, err := io.Copy(w, strings.NewReader(" world"))
+					Expect(errors.Is(err, os.ErrDeadlineExceeded)).To(BeTrue())
+					w.Header().Set("X-Checksum", "5d41402a")
+				}), w, req)
+
+				status, header, body := response()
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(body).To(Equal("hello"))
+				Expect(header.Get("X-Checksum")).To(Equal("5d41402a"))
+				Expect(header).ToNot(HaveKey("Trailer"))
+			})
+
+			It("Keeps the first final status", func() {
+				w, response := newWriter()
+				w.Header().Set("Link", "</style.css>; rel=preload")
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusAccepted)
+				io.WriteString(w, "created")
+
+				status, header, body := response()
+				Expect(status).To(Equal(http.StatusCreated))
+				Expect(header.Get("Content-Length")).To(Equal("7"))
+				Expect(body).To(Equal("created"))
+			})
+
+			It("Suppresses bodies where they are not allowed", func() {
+				w, response := newWriter()
+				w.Header().Set("Content-Length", "5")
+				w.WriteHeader(http.StatusNoContent)
+				_, err := w.Write([]byte("hello"))
+				Expect(err).To(Equal(http.ErrBodyNotAllowed))
+
+				status, header, body := response()
+				Expect(status).To(Equal(http.StatusNoContent))
+				Expect(header).ToNot(HaveKey("Content-Length"))
+				Expect(body).To(Equal(""))
+			})
+
+			It("Measures the bodies of responses to HEAD requests", func() {
+				w, response := newWriter()
+				w.BindRequest(httptest.NewRequest(http.MethodHead, "/hello", nil), time.Second)
+				n, err := io.WriteString(w, "<html></html>")
+				Expect(err).To(BeNil())
+				Expect(n).To(Equal(13))
+
+				status, header, body := response()
+				Expect(status).To(Equal(http.StatusOK))
+				Expect(header.Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+				Expect(header.Get("Content-Length")).To(Equal("13"))
+				Expect(body).To(Equal(""))
+			})
+
+			It("Applies the status semantics through ServeWithTimeout", func() {
+				w, response := newWriter()
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+				req := w.BindRequest(httptest.NewRequest(http.MethodGet, "/hello", nil).WithContext(ctx), time.Second)
+
+				core.ServeWithTimeout(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.WriteHeader(http.StatusContinue)
+					w.WriteHeader(http.StatusNotModified)
+					w.WriteHeader(http.StatusOK)
+					_, err := w.Write([]byte("hello"))
+					Expect(err).To(Equal(http.ErrBodyNotAllowed))
+				}), w, req)
+
+				status, _, body := response()
+				Expect(status).To(Equal(http.StatusNotModified))
+				Expect(body).To(Equal(""))
+			})
+		})
+	}
+
+	It("Rejects invalid status codes", func() {
+		for _, status := range []int{0, 99, 600, 999} {
+			w := core.NewProxyResponseWriter()
+			w.WriteHeader(status)
+			_, err := w.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+
+			w2 := core.NewProxyResponseWriterV2()
+			w2.WriteHeader(status)
+			_, err = w2.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+
+			wALB := core.NewProxyResponseWriterALB()
+			wALB.WriteHeader(status)
+			_, err = wALB.GetProxyResponse()
+			Expect(err).ToNot(BeNil())
+		}
+	})
+})
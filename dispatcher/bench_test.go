@@ -0,0 +1,25 @@
+package dispatcher_test
+
+import (
+	"testing"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/dispatcher"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+)
+
+func newBenchmarkDispatcher() *dispatcher.Dispatcher {
+	d := dispatcher.New()
+	d.Handle(dispatcher.Route{Domain: "api.example.com"}, proxybench.Handler)
+	return d
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	d := newBenchmarkDispatcher()
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway:   d.ProxyWithContext,
+		APIGatewayV2: d.ProxyWithContextV2,
+		ALB:          d.ProxyWithContextALB,
+	})
+}
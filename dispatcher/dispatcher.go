@@ -0,0 +1,205 @@
+// Package dispatcher serves several http.Handler applications from a single
+// Lambda function. Requests are dispatched on the domain name, base path and
+// stage of the API Gateway or ALB event.
+package dispatcher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// notFoundResponseBody is the body of the default response for requests that
+// match no route. It mirrors the message API Gateway returns for unknown
+// routes.
+const notFoundResponseBody = `{"message":"Not Found"}`
+
+// Route identifies the requests served by an application.
+type Route struct {
+	// Domain is the domain name of the request. It can be an exact name such as
+	// api.example.com or a wildcard such as *.example.com that matches any
+	// subdomain. An empty Domain or * matches any domain.
+	Domain string
+
+	// BasePath is the path prefix of the requests, such as the base path
+	// mapping of a custom domain name. It is removed from the request path
+	// before the request is sent to the application, like StripBasePath does.
+	// An empty BasePath matches any path.
+	BasePath string
+
+	// Stage is the API Gateway stage of the request. An empty Stage matches any
+	// stage. ALB events have no stage and only match routes without one.
+	Stage string
+}
+
+type route struct {
+	Route
+	basePath string
+	handler  http.Handler
+	v1       core.RequestAccessor
+	v2       core.RequestAccessorV2
+	alb      core.RequestAccessorALB
+}
+
+// Dispatcher sends API Gateway REST API, HTTP API and ALB events to the
+// application registered for their domain name, base path and stage.
+// When several routes match a request the one with the longest base path is
+// used, then the one with the most specific domain and then the one with a
+// stage. Remaining ties go to the route registered first.
+type Dispatcher struct {
+	// NotFoundHandler serves the requests that match no route. If nil, a 404
+	// response with a JSON message is returned.
+	NotFoundHandler http.Handler
+
+	opts     []core.Option
+	routes   []*route
+	notFound *route
+}
+
+// New creates a new Dispatcher. The given options configure how proxy events
+// are converted for all routes, see core.Option.
+func New(opts ...core.Option) *Dispatcher {
+	d := &Dispatcher{opts: opts}
+	d.notFound = d.newRoute(Route{}, nil)
+	return d
+}
+
+// Handle registers handler as the application serving the requests that
+// match r.
+func (d *Dispatcher) Handle(r Route, handler http.Handler) {
+	d.routes = append(d.routes, d.newRoute(r, handler))
+}
+
+func (d *Dispatcher) newRoute(r Route, handler http.Handler) *route {
+	rt := &route{Route: r, handler: handler}
+	rt.v1.ApplyOptions(d.opts...)
+	rt.v2.ApplyOptions(d.opts...)
+	rt.alb.ApplyOptions(d.opts...)
+	if strings.Trim(r.BasePath, " ") != "" {
+		rt.basePath = rt.v1.StripBasePath(r.BasePath)
+		rt.v2.StripBasePath(r.BasePath)
+		rt.alb.StripBasePath(r.BasePath)
+	}
+	return rt
+}
+
+// match returns the most specific route matching the request, or the
+// not found route if there is none.
+func (d *Dispatcher) match(domain, stage, path string) *route {
+	var best *route
+	for _, rt := range d.routes {
+		if !rt.matches(domain, stage, path) {
+			continue
+		}
+		if best == nil || rt.moreSpecific(best) {
+			best = rt
+		}
+	}
+	if best == nil {
+		return d.notFound
+	}
+	return best
+}
+
+func (d *Dispatcher) handler(rt *route) http.Handler {
+	if rt.handler != nil {
+		return rt.handler
+	}
+	if d.NotFoundHandler != nil {
+		return d.NotFoundHandler
+	}
+	return http.HandlerFunc(notFound)
+}
+
+func notFound(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(notFoundResponseBody))
+}
+
+func (rt *route) matches(domain, stage, path string) bool {
+	if rt.Stage != "" && rt.Stage != stage {
+		return false
+	}
+	if !matchDomain(rt.Domain, domain) {
+		return false
+	}
+	return rt.basePath == "" || path == rt.basePath || strings.HasPrefix(path, rt.basePath+"/")
+}
+
+func (rt *route) moreSpecific(other *route) bool {
+	if len(rt.basePath) != len(other.basePath) {
+		return len(rt.basePath) > len(other.basePath)
+	}
+	if domainSpecificity(rt.Domain) != domainSpecificity(other.Domain) {
+		return domainSpecificity(rt.Domain) > domainSpecificity(other.Domain)
+	}
+	return rt.Stage != "" && other.Stage == ""
+}
+
+func matchDomain(pattern, domain string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return len(domain) > len(suffix) && strings.EqualFold(domain[len(domain)-len(suffix):], suffix)
+	}
+	return strings.EqualFold(pattern, domain)
+}
+
+func domainSpecificity(pattern string) int {
+	switch {
+	case pattern == "" || pattern == "*":
+		return 0
+	case strings.HasPrefix(pattern, "*."):
+		return 1
+	}
+	return 2
+}
+
+// Proxy receives an API Gateway proxy event, transforms it into an http.Request
+// object, and sends it to the application registered for the request.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (d *Dispatcher) Proxy(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	rt := d.match(event.RequestContext.DomainName, event.RequestContext.Stage, event.Path)
+	req, err := rt.v1.ProxyEventToHTTPRequest(event)
+	return d.proxyInternal(context.Background(), event, rt, req, err)
+}
+
+// ProxyWithContext receives context and an API Gateway proxy event,
+// transforms them into an http.Request object, and sends it to the application
+// registered for the request.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (d *Dispatcher) ProxyWithContext(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	rt := d.match(event.RequestContext.DomainName, event.RequestContext.Stage, event.Path)
+	req, err := rt.v1.EventToRequestWithContext(ctx, event)
+	return d.proxyInternal(ctx, event, rt, req, err)
+}
+
+func (d *Dispatcher) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, rt *route, req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+	if err != nil {
+		return rt.v1.ErrorResponse(ctx, event, err)
+	}
+
+	w := rt.v1.NewProxyResponseWriter()
+	defer w.Release()
+	req = w.BindRequest(req, rt.v1.TimeoutReserve())
+	if err := rt.v1.Serve(d.handler(rt), w, req); err != nil {
+		return rt.v1.ErrorResponse(ctx, event, err)
+	}
+
+	resp, err := w.GetProxyResponse()
+	if err != nil {
+		return rt.v1.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,49 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// ProxyALB receives an ALB Target Group Request event, transforms it into an
+// http.Request object, and sends it to the application registered for the
+// request.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (d *Dispatcher) ProxyALB(event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	rt := d.match(core.HostALB(event), "", event.Path)
+	req, err := rt.alb.ProxyEventToHTTPRequest(event)
+	return d.proxyInternalALB(context.Background(), event, rt, req, err)
+}
+
+// ProxyWithContextALB receives context and an ALB Target Group Request event,
+// transforms them into an http.Request object, and sends it to the application
+// registered for the request.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (d *Dispatcher) ProxyWithContextALB(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	rt := d.match(core.HostALB(event), "", event.Path)
+	req, err := rt.alb.EventToRequestWithContext(ctx, event)
+	return d.proxyInternalALB(ctx, event, rt, req, err)
+}
+
+func (d *Dispatcher) proxyInternalALB(ctx context.Context, event events.ALBTargetGroupRequest, rt *route, req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
+	if err != nil {
+		return rt.alb.ErrorResponse(ctx, event, err)
+	}
+
+	w := rt.alb.NewProxyResponseWriterALB()
+	defer w.Release()
+	req = w.BindRequest(req, rt.alb.TimeoutReserve())
+	if err := rt.alb.Serve(d.handler(rt), w, req); err != nil {
+		return rt.alb.ErrorResponse(ctx, event, err)
+	}
+
+	resp, err := w.GetProxyResponse()
+	if err != nil {
+		return rt.alb.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,174 @@
+package dispatcher_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/dispatcher"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// app returns a handler that writes its name and the path it was sent.
+func app(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s %s", name, req.URL.Path)
+	})
+}
+
+func newDispatcher(opts ...core.Option) *dispatcher.Dispatcher {
+	d := dispatcher.New(opts...)
+	d.Handle(dispatcher.Route{Domain: "api.example.com", BasePath: "users"}, app("users"))
+	d.Handle(dispatcher.Route{Domain: "api.example.com", BasePath: "/users/admin/"}, app("admin"))
+	d.Handle(dispatcher.Route{Domain: "*.example.com", BasePath: "/orders"}, app("orders"))
+	d.Handle(dispatcher.Route{BasePath: "/orders", Stage: "dev"}, app("orders-dev"))
+	d.Handle(dispatcher.Route{Domain: "12abcdefgh.execute-api.us-east-2.amazonaws.com", Stage: "prod"}, app("default"))
+	return d
+}
+
+func restRequest(domain, stage, path string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		Path:       path,
+		HTTPMethod: "GET",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			DomainName: domain,
+			Stage:      stage,
+		},
+	}
+}
+
+var _ = Describe("Dispatcher tests", func() {
+	Context("REST API events", func() {
+		d := newDispatcher()
+
+		cases := []struct {
+			domain, stage, path string
+			body                string
+		}{
+			{"api.example.com", "prod", "/users/1", "users /1"},
+			{"API.example.com", "prod", "/users", "users /"},
+			{"api.example.com", "prod", "/users/admin/settings", "admin /settings"},
+			{"shop.example.com", "prod", "/orders/7", "orders /7"},
+			{"orders.internal", "dev", "/orders/7", "orders-dev /7"},
+			{"12abcdefgh.execute-api.us-east-2.amazonaws.com", "prod", "/anything", "default /anything"},
+		}
+		for _, c := range cases {
+			c := c
+			It(fmt.Sprintf("Dispatches %s%s to the right application", c.domain, c.path), func() {
+				resp, err := d.ProxyWithContext(context.Background(), restRequest(c.domain, c.stage, c.path))
+				Expect(err).To(BeNil())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.Body).To(Equal(c.body))
+			})
+		}
+
+		It("Prefers the exact domain over wildcards", func() {
+			d := dispatcher.New()
+			d.Handle(dispatcher.Route{Domain: "*.example.com", BasePath: "/orders"}, app("wildcard"))
+			d.Handle(dispatcher.Route{Domain: "api.example.com", BasePath: "/orders"}, app("exact"))
+
+			resp, err := d.Proxy(restRequest("api.example.com", "prod", "/orders"))
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("exact /"))
+		})
+
+		It("Does not match partial path segments", func() {
+			resp, err := d.Proxy(restRequest("api.example.com", "prod", "/usersettings"))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		It("Returns a 404 when no route matches", func() {
+			resp, err := d.Proxy(restRequest("other.example.org", "prod", "/users"))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(resp.Body).To(Equal(`{"message":"Not Found"}`))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/json"}))
+		})
+
+		It("Uses the configured not found handler", func() {
+			d := newDispatcher()
+			d.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+				fmt.Fprintf(w, "nothing at %s", req.URL.Path)
+			})
+
+			resp, err := d.Proxy(restRequest("other.example.org", "prod", "/users"))
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusTeapot))
+			Expect(resp.Body).To(Equal("nothing at /users"))
+		})
+
+		It("Applies the options to every route", func() {
+			d := newDispatcher(core.WithCustomHost("http://internal.example.com"))
+			d.Handle(dispatcher.Route{BasePath: "/host"}, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprint(w, req.Host)
+			}))
+
+			resp, err := d.Proxy(restRequest("", "prod", "/host"))
+			Expect(err).To(BeNil())
+			Expect(resp.Body).To(Equal("internal.example.com"))
+		})
+	})
+
+	Context("HTTP API events", func() {
+		It("Dispatches on the raw path", func() {
+			d := newDispatcher()
+			resp, err := d.ProxyWithContextV2(context.Background(), events.APIGatewayV2HTTPRequest{
+				RawPath: "/users/admin/roles",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					DomainName: "api.example.com",
+					Stage:      "$default",
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+						Method: "GET",
+						Path:   "/users/admin/roles",
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Body).To(Equal("admin /roles"))
+
+			resp, err = d.ProxyV2(events.APIGatewayV2HTTPRequest{
+				RawPath: "/missing",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					DomainName: "api.example.com",
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+						Method: "GET",
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("ALB events", func() {
+		It("Dispatches on the host header", func() {
+			d := newDispatcher()
+			resp, err := d.ProxyWithContextALB(context.Background(), events.ALBTargetGroupRequest{
+				HTTPMethod:        "GET",
+				Path:              "/orders/1",
+				MultiValueHeaders: map[string][]string{"host": {"shop.example.com"}},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Body).To(Equal("orders /1"))
+		})
+
+		It("Does not match routes with a stage", func() {
+			d := newDispatcher()
+			resp, err := d.ProxyALB(events.ALBTargetGroupRequest{
+				HTTPMethod: "GET",
+				Path:       "/orders/1",
+				Headers:    map[string]string{"host": "orders.internal"},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+})
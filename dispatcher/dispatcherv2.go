@@ -0,0 +1,57 @@
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+)
+
+// ProxyV2 receives an API Gateway HTTP API event, transforms it into an
+// http.Request object, and sends it to the application registered for the
+// request.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (d *Dispatcher) ProxyV2(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	rt := d.matchV2(event)
+	req, err := rt.v2.ProxyEventToHTTPRequest(event)
+	return d.proxyInternalV2(context.Background(), event, rt, req, err)
+}
+
+// ProxyWithContextV2 receives context and an API Gateway HTTP API event,
+// transforms them into an http.Request object, and sends it to the application
+// registered for the request.
+// It returns a proxy response object generated from the http.ResponseWriter.
+func (d *Dispatcher) ProxyWithContextV2(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	rt := d.matchV2(event)
+	req, err := rt.v2.EventToRequestWithContext(ctx, event)
+	return d.proxyInternalV2(ctx, event, rt, req, err)
+}
+
+func (d *Dispatcher) matchV2(event events.APIGatewayV2HTTPRequest) *route {
+	path := event.RawPath
+	if len(path) == 0 {
+		path = event.RequestContext.HTTP.Path
+	}
+	return d.match(event.RequestContext.DomainName, event.RequestContext.Stage, path)
+}
+
+func (d *Dispatcher) proxyInternalV2(ctx context.Context, event events.APIGatewayV2HTTPRequest, rt *route, req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
+	if err != nil {
+		return rt.v2.ErrorResponse(ctx, event, err)
+	}
+
+	w := rt.v2.NewProxyResponseWriterV2()
+	defer w.Release()
+	req = w.BindRequest(req, rt.v2.TimeoutReserve())
+	if err := rt.v2.Serve(d.handler(rt), w, req); err != nil {
+		return rt.v2.ErrorResponse(ctx, event, err)
+	}
+
+	resp, err := w.GetProxyResponse()
+	if err != nil {
+		return rt.v2.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
+	}
+
+	return resp, nil
+}
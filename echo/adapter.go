@@ -23,9 +23,12 @@ type EchoLambda struct {
 
 // New creates a new instance of the EchoLambda object.
 // Receives an initialized *echo.Echo object - normally created with echo.New().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the EchoLambda object.
-func New(e *echo.Echo) *EchoLambda {
-	return &EchoLambda{Echo: e}
+func New(e *echo.Echo, opts ...core.Option) *EchoLambda {
+	l := &EchoLambda{Echo: e}
+	l.ApplyOptions(opts...)
+	return l
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -33,7 +36,7 @@ func New(e *echo.Echo) *EchoLambda {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	echoRequest, err := e.ProxyEventToHTTPRequest(req)
-	return e.proxyInternal(echoRequest, err)
+	return e.proxyInternal(context.Background(), req, echoRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -41,21 +44,25 @@ func (e *EchoLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGateway
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	echoRequest, err := e.EventToRequestWithContext(ctx, req)
-	return e.proxyInternal(echoRequest, err)
+	return e.proxyInternal(ctx, req, echoRequest, err)
 }
 
-func (e *EchoLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+func (e *EchoLambda) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return e.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriter()
-	e.Echo.ServeHTTP(http.ResponseWriter(respWriter), req)
+	respWriter := e.NewProxyResponseWriter()
+	defer respWriter.Release()
+	req = respWriter.BindRequest(req, e.TimeoutReserve())
+	if err := e.Serve(e.Echo, respWriter, req); err != nil {
+		return e.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return e.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
@@ -20,9 +20,12 @@ type EchoLambdaALB struct {
 
 // NewAPI creates a new instance of the EchoLambdaAPI object.
 // Receives an initialized *echo.Echo object - normally created with echo.New().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the EchoLambdaALB object.
-func NewALB(e *echo.Echo) *EchoLambdaALB {
-	return &EchoLambdaALB{Echo: e}
+func NewALB(e *echo.Echo, opts ...core.Option) *EchoLambdaALB {
+	l := &EchoLambdaALB{Echo: e}
+	l.ApplyOptions(opts...)
+	return l
 }
 
 // Proxy receives an ALB event, transforms it into an http.Request
@@ -30,7 +33,7 @@ func NewALB(e *echo.Echo) *EchoLambdaALB {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambdaALB) Proxy(req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	echoRequest, err := e.ProxyEventToHTTPRequest(req)
-	return e.proxyInternal(echoRequest, err)
+	return e.proxyInternal(context.Background(), req, echoRequest, err)
 }
 
 // ProxyWithContext receives context and an ALB event,
@@ -38,21 +41,25 @@ func (e *EchoLambdaALB) Proxy(req events.ALBTargetGroupRequest) (events.ALBTarge
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambdaALB) ProxyWithContext(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	echoRequest, err := e.EventToRequestWithContext(ctx, req)
-	return e.proxyInternal(echoRequest, err)
+	return e.proxyInternal(ctx, req, echoRequest, err)
 }
 
-func (e *EchoLambdaALB) proxyInternal(req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
+func (e *EchoLambdaALB) proxyInternal(ctx context.Context, event events.ALBTargetGroupRequest, req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return e.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriterALB()
-	e.Echo.ServeHTTP(http.ResponseWriter(respWriter), req)
+	respWriter := e.NewProxyResponseWriterALB()
+	defer respWriter.Release()
+	req = respWriter.BindRequest(req, e.TimeoutReserve())
+	if err := e.Serve(e.Echo, respWriter, req); err != nil {
+		return e.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return e.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
@@ -20,9 +20,12 @@ type EchoLambdaV2 struct {
 
 // NewV2 creates a new instance of the EchoLambda object.
 // Receives an initialized *echo.Echo object - normally created with echo.New().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the EchoLambdaV2 object.
-func NewV2(e *echo.Echo) *EchoLambdaV2 {
-	return &EchoLambdaV2{Echo: e}
+func NewV2(e *echo.Echo, opts ...core.Option) *EchoLambdaV2 {
+	l := &EchoLambdaV2{Echo: e}
+	l.ApplyOptions(opts...)
+	return l
 }
 
 // Proxy receives an API Gateway proxy V2 event, transforms it into an http.Request
@@ -30,7 +33,7 @@ func NewV2(e *echo.Echo) *EchoLambdaV2 {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambdaV2) Proxy(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	echoRequest, err := e.ProxyEventToHTTPRequest(req)
-	return e.proxyInternal(echoRequest, err)
+	return e.proxyInternal(context.Background(), req, echoRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy V2 event,
@@ -38,21 +41,25 @@ func (e *EchoLambdaV2) Proxy(req events.APIGatewayV2HTTPRequest) (events.APIGate
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (e *EchoLambdaV2) ProxyWithContext(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	echoRequest, err := e.EventToRequestWithContext(ctx, req)
-	return e.proxyInternal(echoRequest, err)
+	return e.proxyInternal(ctx, req, echoRequest, err)
 }
 
-func (e *EchoLambdaV2) proxyInternal(req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
+func (e *EchoLambdaV2) proxyInternal(ctx context.Context, event events.APIGatewayV2HTTPRequest, req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return e.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriterV2()
-	e.Echo.ServeHTTP(http.ResponseWriter(respWriter), req)
+	respWriter := e.NewProxyResponseWriterV2()
+	defer respWriter.Release()
+	req = respWriter.BindRequest(req, e.TimeoutReserve())
+	if err := e.Serve(e.Echo, respWriter, req); err != nil {
+		return e.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return e.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
@@ -0,0 +1,28 @@
+package echoadapter_test
+
+import (
+	"net/http"
+	"testing"
+
+	echoadapter "github.com/awslabs/aws-lambda-go-api-proxy/echo"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+	"github.com/labstack/echo/v4"
+)
+
+func newBenchmarkEcho() *echo.Echo {
+	e := echo.New()
+	e.GET("/orders/:id", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "application/json", proxybench.Body)
+	})
+	return e
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway:   echoadapter.New(newBenchmarkEcho()).ProxyWithContext,
+		APIGatewayV2: echoadapter.NewV2(newBenchmarkEcho()).ProxyWithContext,
+		ALB:          echoadapter.NewALB(newBenchmarkEcho()).ProxyWithContext,
+	})
+}
@@ -5,9 +5,7 @@ package fiberadapter
 
 import (
 	"context"
-	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"strings"
@@ -31,11 +29,15 @@ type FiberLambda struct {
 
 // New creates a new instance of the FiberLambda object.
 // Receives an initialized *fiber.App object - normally created with fiber.New().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the FiberLambda object.
-func New(app *fiber.App) *FiberLambda {
-	return &FiberLambda{
+func New(app *fiber.App, opts ...core.Option) *FiberLambda {
+	f := &FiberLambda{
 		app: app,
 	}
+	f.ApplyOptions(opts...)
+	f.v2.ApplyOptions(opts...)
+	return f
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -43,13 +45,13 @@ func New(app *fiber.App) *FiberLambda {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (f *FiberLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	fiberRequest, err := f.ProxyEventToHTTPRequest(req)
-	return f.proxyInternal(fiberRequest, err)
+	return f.proxyInternal(context.Background(), req, fiberRequest, err)
 }
 
 // ProxyV2 is just same as Proxy() but for APIGateway HTTP payload v2
 func (f *FiberLambda) ProxyV2(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	fiberRequest, err := f.v2.ProxyEventToHTTPRequest(req)
-	return f.proxyInternalV2(fiberRequest, err)
+	return f.proxyInternalV2(context.Background(), req, fiberRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -57,44 +59,52 @@ func (f *FiberLambda) ProxyV2(req events.APIGatewayV2HTTPRequest) (events.APIGat
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (f *FiberLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	fiberRequest, err := f.EventToRequestWithContext(ctx, req)
-	return f.proxyInternal(fiberRequest, err)
+	return f.proxyInternal(ctx, req, fiberRequest, err)
 }
 
 // ProxyWithContextV2 is just same as ProxyWithContext() but for APIGateway HTTP payload v2
 func (f *FiberLambda) ProxyWithContextV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	fiberRequest, err := f.v2.EventToRequestWithContext(ctx, req)
-	return f.proxyInternalV2(fiberRequest, err)
+	return f.proxyInternalV2(ctx, req, fiberRequest, err)
 }
 
-func (f *FiberLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+func (f *FiberLambda) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return f.ErrorResponse(ctx, event, err)
 	}
 
-	resp := core.NewProxyResponseWriter()
-	f.adaptor(resp, req)
+	resp := f.NewProxyResponseWriter()
+	defer resp.Release()
+	req = resp.BindRequest(req, f.TimeoutReserve())
+	if err := f.Serve(http.HandlerFunc(f.adaptor), resp, req); err != nil {
+		return f.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := resp.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return f.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
 }
 
-func (f *FiberLambda) proxyInternalV2(req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
+func (f *FiberLambda) proxyInternalV2(ctx context.Context, event events.APIGatewayV2HTTPRequest, req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return f.v2.ErrorResponse(ctx, event, err)
 	}
 
-	resp := core.NewProxyResponseWriterV2()
-	f.adaptor(resp, req)
+	resp := f.v2.NewProxyResponseWriterV2()
+	defer resp.Release()
+	req = resp.BindRequest(req, f.v2.TimeoutReserve())
+	if err := f.v2.Serve(http.HandlerFunc(f.adaptor), resp, req); err != nil {
+		return f.v2.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := resp.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return f.v2.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
@@ -140,8 +150,7 @@ func (f *FiberLambda) adaptor(w http.ResponseWriter, r *http.Request) {
 
 	remoteAddr, err := net.ResolveTCPAddr("tcp", addrWithPort)
 	if err != nil {
-		fmt.Printf("could not resolve TCP address for addr %s\n", r.RemoteAddr)
-		log.Println(err)
+		f.RequestLogger(r).Error("Could not resolve TCP address", "addr", r.RemoteAddr, "error", err)
 		http.Error(w, utils.StatusMessage(fiber.StatusInternalServerError), fiber.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,28 @@
+package fiberadapter_test
+
+import (
+	"testing"
+
+	fiberadaptor "github.com/awslabs/aws-lambda-go-api-proxy/fiber"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newBenchmarkApp() *fiber.App {
+	app := fiber.New()
+	app.Get("/orders/:id", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(proxybench.Body)
+	})
+	return app
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	adapter := fiberadaptor.New(newBenchmarkApp())
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway:   adapter.ProxyWithContext,
+		APIGatewayV2: adapter.ProxyWithContextV2,
+	})
+}
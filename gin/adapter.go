@@ -23,9 +23,12 @@ type GinLambda struct {
 
 // New creates a new instance of the GinLambda object.
 // Receives an initialized *gin.Engine object - normally created with gin.Default().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the GinLambda object.
-func New(gin *gin.Engine) *GinLambda {
-	return &GinLambda{ginEngine: gin}
+func New(gin *gin.Engine, opts ...core.Option) *GinLambda {
+	g := &GinLambda{ginEngine: gin}
+	g.ApplyOptions(opts...)
+	return g
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -33,7 +36,7 @@ func New(gin *gin.Engine) *GinLambda {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *GinLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	ginRequest, err := g.ProxyEventToHTTPRequest(req)
-	return g.proxyInternal(ginRequest, err)
+	return g.proxyInternal(context.Background(), req, ginRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -41,21 +44,25 @@ func (g *GinLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayP
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *GinLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	ginRequest, err := g.EventToRequestWithContext(ctx, req)
-	return g.proxyInternal(ginRequest, err)
+	return g.proxyInternal(ctx, req, ginRequest, err)
 }
 
-func (g *GinLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+func (g *GinLambda) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return g.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriter()
-	g.ginEngine.ServeHTTP(http.ResponseWriter(respWriter), req)
+	respWriter := g.NewProxyResponseWriter()
+	defer respWriter.Release()
+	req = respWriter.BindRequest(req, g.TimeoutReserve())
+	if err := g.Serve(g.ginEngine, respWriter, req); err != nil {
+		return g.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return g.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
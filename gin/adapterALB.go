@@ -23,9 +23,12 @@ type GinLambdaALB struct {
 
 // New creates a new instance of the GinLambdaALB object.
 // Receives an initialized *gin.Engine object - normally created with gin.Default().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the GinLambdaALB object.
-func NewALB(gin *gin.Engine) *GinLambdaALB {
-	return &GinLambdaALB{ginEngine: gin}
+func NewALB(gin *gin.Engine, opts ...core.Option) *GinLambdaALB {
+	g := &GinLambdaALB{ginEngine: gin}
+	g.ApplyOptions(opts...)
+	return g
 }
 
 // Proxy receives an ALB proxy event, transforms it into an http.Request
@@ -33,7 +36,7 @@ func NewALB(gin *gin.Engine) *GinLambdaALB {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *GinLambdaALB) Proxy(req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	ginRequest, err := g.ProxyEventToHTTPRequest(req)
-	return g.proxyInternal(ginRequest, err)
+	return g.proxyInternal(context.Background(), req, ginRequest, err)
 }
 
 // ProxyWithContext receives context and an ALB proxy event,
@@ -41,21 +44,25 @@ func (g *GinLambdaALB) Proxy(req events.ALBTargetGroupRequest) (events.ALBTarget
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (g *GinLambdaALB) ProxyWithContext(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	ginRequest, err := g.EventToRequestWithContext(ctx, req)
-	return g.proxyInternal(ginRequest, err)
+	return g.proxyInternal(ctx, req, ginRequest, err)
 }
 
-func (g *GinLambdaALB) proxyInternal(req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
+func (g *GinLambdaALB) proxyInternal(ctx context.Context, event events.ALBTargetGroupRequest, req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return g.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriterALB()
-	g.ginEngine.ServeHTTP(http.ResponseWriter(respWriter), req)
+	respWriter := g.NewProxyResponseWriterALB()
+	defer respWriter.Release()
+	req = respWriter.BindRequest(req, g.TimeoutReserve())
+	if err := g.Serve(g.ginEngine, respWriter, req); err != nil {
+		return g.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return g.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
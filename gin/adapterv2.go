@@ -20,9 +20,12 @@ type GinLambdaV2 struct {
 
 // NewV2 creates a new instance of the GinLambdaV2 object.
 // Receives an initialized *gin.Engine object - normally created with gin.Default().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the GinLambdaV2 object.
-func NewV2(gin *gin.Engine) *GinLambdaV2 {
-	return &GinLambdaV2{ginEngine: gin}
+func NewV2(gin *gin.Engine, opts ...core.Option) *GinLambdaV2 {
+	g := &GinLambdaV2{ginEngine: gin}
+	g.ApplyOptions(opts...)
+	return g
 }
 
 // Proxy receives an API Gateway proxy V2 event, transforms it into an http.Request
@@ -30,7 +33,7 @@ func NewV2(gin *gin.Engine) *GinLambdaV2 {
 // It returns an http response object generated from the http.ResponseWriter.
 func (g *GinLambdaV2) Proxy(req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	ginRequest, err := g.ProxyEventToHTTPRequest(req)
-	return g.proxyInternal(ginRequest, err)
+	return g.proxyInternal(context.Background(), req, ginRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy V2 event,
@@ -38,21 +41,25 @@ func (g *GinLambdaV2) Proxy(req events.APIGatewayV2HTTPRequest) (events.APIGatew
 // It returns an http response object generated from the http.ResponseWriter.
 func (g *GinLambdaV2) ProxyWithContext(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	ginRequest, err := g.EventToRequestWithContext(ctx, req)
-	return g.proxyInternal(ginRequest, err)
+	return g.proxyInternal(ctx, req, ginRequest, err)
 }
 
-func (g *GinLambdaV2) proxyInternal(req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
+func (g *GinLambdaV2) proxyInternal(ctx context.Context, event events.APIGatewayV2HTTPRequest, req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
 
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return g.ErrorResponse(ctx, event, err)
 	}
 
-	respWriter := core.NewProxyResponseWriterV2()
-	g.ginEngine.ServeHTTP(http.ResponseWriter(respWriter), req)
+	respWriter := g.NewProxyResponseWriterV2()
+	defer respWriter.Release()
+	req = respWriter.BindRequest(req, g.TimeoutReserve())
+	if err := g.Serve(g.ginEngine, respWriter, req); err != nil {
+		return g.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return g.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
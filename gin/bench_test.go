@@ -0,0 +1,29 @@
+package ginadapter_test
+
+import (
+	"net/http"
+	"testing"
+
+	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+	"github.com/gin-gonic/gin"
+)
+
+func newBenchmarkEngine() *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.GET("/orders/:id", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", proxybench.Body)
+	})
+	return r
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway:   ginadapter.New(newBenchmarkEngine()).ProxyWithContext,
+		APIGatewayV2: ginadapter.NewV2(newBenchmarkEngine()).ProxyWithContext,
+		ALB:          ginadapter.NewALB(newBenchmarkEngine()).ProxyWithContext,
+	})
+}
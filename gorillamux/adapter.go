@@ -2,9 +2,9 @@ package gorillamux
 
 import (
 	"context"
-	"errors"
 	"net/http"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/gorilla/mux"
 )
@@ -15,10 +15,13 @@ type GorillaMuxAdapter struct {
 	router *mux.Router
 }
 
-func New(router *mux.Router) *GorillaMuxAdapter {
-	return &GorillaMuxAdapter{
+func New(router *mux.Router, opts ...core.Option) *GorillaMuxAdapter {
+	h := &GorillaMuxAdapter{
 		router: router,
 	}
+	h.RequestAccessor.ApplyOptions(opts...)
+	h.RequestAccessorV2.ApplyOptions(opts...)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event or API Gateway V2 event, transforms it into an http.Request
@@ -27,12 +30,12 @@ func New(router *mux.Router) *GorillaMuxAdapter {
 func (h *GorillaMuxAdapter) Proxy(event core.SwitchableAPIGatewayRequest) (*core.SwitchableAPIGatewayResponse, error) {
 	if event.Version1() != nil {
 		req, err := h.RequestAccessor.ProxyEventToHTTPRequest(*event.Version1())
-		return h.proxyInternal(req, err)
+		return h.proxyInternal(context.Background(), *event.Version1(), req, err)
 	} else if event.Version2() != nil {
 		req, err := h.RequestAccessorV2.ProxyEventToHTTPRequest(*event.Version2())
-		return h.proxyInternalV2(req, err)
+		return h.proxyInternalV2(context.Background(), *event.Version2(), req, err)
 	} else {
-		return &core.SwitchableAPIGatewayResponse{}, core.NewLoggedError("Could not convert proxy event to request: %v", errors.New("Unable to determine version "))
+		return &core.SwitchableAPIGatewayResponse{}, h.unknownVersion()
 	}
 }
 
@@ -42,47 +45,65 @@ func (h *GorillaMuxAdapter) Proxy(event core.SwitchableAPIGatewayRequest) (*core
 func (h *GorillaMuxAdapter) ProxyWithContext(ctx context.Context, event core.SwitchableAPIGatewayRequest) (*core.SwitchableAPIGatewayResponse, error) {
 	if event.Version1() != nil {
 		req, err := h.RequestAccessor.EventToRequestWithContext(ctx, *event.Version1())
-		return h.proxyInternal(req, err)
+		return h.proxyInternal(ctx, *event.Version1(), req, err)
 	} else if event.Version2() != nil {
 		req, err := h.RequestAccessorV2.EventToRequestWithContext(ctx, *event.Version2())
-		return h.proxyInternalV2(req, err)
+		return h.proxyInternalV2(ctx, *event.Version2(), req, err)
 	} else {
-		return &core.SwitchableAPIGatewayResponse{}, core.NewLoggedError("Could not convert proxy event to request: %v", errors.New("Unable to determine version "))
+		return &core.SwitchableAPIGatewayResponse{}, h.unknownVersion()
 	}
 }
 
-func (h *GorillaMuxAdapter) proxyInternal(req *http.Request, err error) (*core.SwitchableAPIGatewayResponse, error) {
+func (h *GorillaMuxAdapter) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, req *http.Request, err error) (*core.SwitchableAPIGatewayResponse, error) {
 	if err != nil {
-		timeout := core.GatewayTimeout()
-		return core.NewSwitchableAPIGatewayResponseV1(&timeout), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		resp, err := h.RequestAccessor.ErrorResponse(ctx, event, err)
+		return core.NewSwitchableAPIGatewayResponseV1(&resp), err
 	}
 
-	w := core.NewProxyResponseWriter()
-	h.router.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.RequestAccessor.NewProxyResponseWriter()
+	defer w.Release()
+	req = w.BindRequest(req, h.RequestAccessor.TimeoutReserve())
+	if err := h.RequestAccessor.Serve(h.router, w, req); err != nil {
+		resp, err := h.RequestAccessor.ErrorResponse(ctx, event, err)
+		return core.NewSwitchableAPIGatewayResponseV1(&resp), err
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		timeout := core.GatewayTimeout()
-		return core.NewSwitchableAPIGatewayResponseV1(&timeout), core.NewLoggedError("Error while generating proxy response: %v", err)
+		resp, err := h.RequestAccessor.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
+		return core.NewSwitchableAPIGatewayResponseV1(&resp), err
 	}
 
 	return core.NewSwitchableAPIGatewayResponseV1(&resp), nil
 }
 
-func (h *GorillaMuxAdapter) proxyInternalV2(req *http.Request, err error) (*core.SwitchableAPIGatewayResponse, error) {
+func (h *GorillaMuxAdapter) proxyInternalV2(ctx context.Context, event events.APIGatewayV2HTTPRequest, req *http.Request, err error) (*core.SwitchableAPIGatewayResponse, error) {
 	if err != nil {
-		timeout := core.GatewayTimeoutV2()
-		return core.NewSwitchableAPIGatewayResponseV2(&timeout), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		resp, err := h.RequestAccessorV2.ErrorResponse(ctx, event, err)
+		return core.NewSwitchableAPIGatewayResponseV2(&resp), err
 	}
 
-	w := core.NewProxyResponseWriterV2()
-	h.router.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.RequestAccessorV2.NewProxyResponseWriterV2()
+	defer w.Release()
+	req = w.BindRequest(req, h.RequestAccessorV2.TimeoutReserve())
+	if err := h.RequestAccessorV2.Serve(h.router, w, req); err != nil {
+		resp, err := h.RequestAccessorV2.ErrorResponse(ctx, event, err)
+		return core.NewSwitchableAPIGatewayResponseV2(&resp), err
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		timeout := core.GatewayTimeoutV2()
-		return core.NewSwitchableAPIGatewayResponseV2(&timeout), core.NewLoggedError("Error while generating proxy response: %v", err)
+		resp, err := h.RequestAccessorV2.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
+		return core.NewSwitchableAPIGatewayResponseV2(&resp), err
 	}
 
 	return core.NewSwitchableAPIGatewayResponseV2(&resp), nil
 }
+
+// unknownVersion logs and returns the error for events whose payload version
+// cannot be determined.
+func (h *GorillaMuxAdapter) unknownVersion() error {
+	err := &core.ConversionError{Err: core.ErrUnknownVersion}
+	h.RequestAccessor.Logger().Error("Could not convert proxy event to request", "error", err)
+	return err
+}
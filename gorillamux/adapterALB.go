@@ -14,10 +14,12 @@ type GorillaMuxAdapterALB struct {
 	router *mux.Router
 }
 
-func NewALB(router *mux.Router) *GorillaMuxAdapterALB {
-	return &GorillaMuxAdapterALB{
+func NewALB(router *mux.Router, opts ...core.Option) *GorillaMuxAdapterALB {
+	h := &GorillaMuxAdapterALB{
 		router: router,
 	}
+	h.ApplyOptions(opts...)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -25,7 +27,7 @@ func NewALB(router *mux.Router) *GorillaMuxAdapterALB {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *GorillaMuxAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(context.Background(), event, req, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -33,20 +35,24 @@ func (h *GorillaMuxAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *GorillaMuxAdapterALB) ProxyWithContext(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(ctx, event, req, err)
 }
 
-func (h *GorillaMuxAdapterALB) proxyInternal(req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
+func (h *GorillaMuxAdapterALB) proxyInternal(ctx context.Context, event events.ALBTargetGroupRequest, req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return h.ErrorResponse(ctx, event, err)
 	}
 
-	w := core.NewProxyResponseWriterALB()
-	h.router.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.NewProxyResponseWriterALB()
+	defer w.Release()
+	req = w.BindRequest(req, h.TimeoutReserve())
+	if err := h.Serve(h.router, w, req); err != nil {
+		return h.ErrorResponse(ctx, event, err)
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return h.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return resp, nil
@@ -14,10 +14,12 @@ type GorillaMuxAdapterV2 struct {
 	router *mux.Router
 }
 
-func NewV2(router *mux.Router) *GorillaMuxAdapterV2 {
-	return &GorillaMuxAdapterV2{
+func NewV2(router *mux.Router, opts ...core.Option) *GorillaMuxAdapterV2 {
+	h := &GorillaMuxAdapterV2{
 		router: router,
 	}
+	h.ApplyOptions(opts...)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -25,7 +27,7 @@ func NewV2(router *mux.Router) *GorillaMuxAdapterV2 {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *GorillaMuxAdapterV2) Proxy(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(context.Background(), event, req, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -33,20 +35,24 @@ func (h *GorillaMuxAdapterV2) Proxy(event events.APIGatewayV2HTTPRequest) (event
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *GorillaMuxAdapterV2) ProxyWithContext(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(ctx, event, req, err)
 }
 
-func (h *GorillaMuxAdapterV2) proxyInternal(req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
+func (h *GorillaMuxAdapterV2) proxyInternal(ctx context.Context, event events.APIGatewayV2HTTPRequest, req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return h.ErrorResponse(ctx, event, err)
 	}
 
-	w := core.NewProxyResponseWriterV2()
-	h.router.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.NewProxyResponseWriterV2()
+	defer w.Release()
+	req = w.BindRequest(req, h.TimeoutReserve())
+	if err := h.Serve(h.router, w, req); err != nil {
+		return h.ErrorResponse(ctx, event, err)
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return h.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return resp, nil
@@ -0,0 +1,39 @@
+package gorillamux_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/awslabs/aws-lambda-go-api-proxy/gorillamux"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+	"github.com/gorilla/mux"
+)
+
+func newBenchmarkRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/orders/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(proxybench.Body)
+	}).Methods(http.MethodGet)
+	return r
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	adapter := gorillamux.New(newBenchmarkRouter())
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway: func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			resp, err := adapter.ProxyWithContext(ctx, *core.NewSwitchableAPIGatewayRequestV1(&event))
+			if err != nil {
+				return events.APIGatewayProxyResponse{}, err
+			}
+			return *resp.Version1(), nil
+		},
+		APIGatewayV2: gorillamux.NewV2(newBenchmarkRouter()).ProxyWithContext,
+		ALB:          gorillamux.NewALB(newBenchmarkRouter()).ProxyWithContext,
+	})
+}
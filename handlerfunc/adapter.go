@@ -3,11 +3,12 @@ package handlerfunc
 import (
 	"net/http"
 
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
 )
 
 type HandlerFuncAdapter = httpadapter.HandlerAdapter
 
-func New(handlerFunc http.HandlerFunc) *HandlerFuncAdapter {
-	return httpadapter.New(handlerFunc)
+func New(handlerFunc http.HandlerFunc, opts ...core.Option) *HandlerFuncAdapter {
+	return httpadapter.New(handlerFunc, opts...)
 }
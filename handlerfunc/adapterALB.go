@@ -3,11 +3,12 @@ package handlerfunc
 import (
 	"net/http"
 
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
 )
 
 type HandlerFuncAdapterALB = httpadapter.HandlerAdapterALB
 
-func NewALB(handlerFunc http.HandlerFunc) *HandlerFuncAdapterALB {
-	return httpadapter.NewALB(handlerFunc)
+func NewALB(handlerFunc http.HandlerFunc, opts ...core.Option) *HandlerFuncAdapterALB {
+	return httpadapter.NewALB(handlerFunc, opts...)
 }
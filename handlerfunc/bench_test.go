@@ -0,0 +1,18 @@
+package handlerfunc_test
+
+import (
+	"testing"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/handlerfunc"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+)
+
+// BenchmarkProxy measures the time and allocations of proxying an event to
+// the handler function for every event type, without the routing of a framework.
+func BenchmarkProxy(b *testing.B) {
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway:   handlerfunc.New(proxybench.Handler).ProxyWithContext,
+		APIGatewayV2: handlerfunc.NewV2(proxybench.Handler).ProxyWithContext,
+		ALB:          handlerfunc.NewALB(proxybench.Handler).ProxyWithContext,
+	})
+}
@@ -13,10 +13,12 @@ type HandlerAdapter struct {
 	handler http.Handler
 }
 
-func New(handler http.Handler) *HandlerAdapter {
-	return &HandlerAdapter{
+func New(handler http.Handler, opts ...core.Option) *HandlerAdapter {
+	h := &HandlerAdapter{
 		handler: handler,
 	}
+	h.ApplyOptions(opts...)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -24,7 +26,7 @@ func New(handler http.Handler) *HandlerAdapter {
 // It returns a proxy response object generated from the http.Handler.
 func (h *HandlerAdapter) Proxy(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(context.Background(), event, req, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -32,20 +34,24 @@ func (h *HandlerAdapter) Proxy(event events.APIGatewayProxyRequest) (events.APIG
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *HandlerAdapter) ProxyWithContext(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(ctx, event, req, err)
 }
 
-func (h *HandlerAdapter) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+func (h *HandlerAdapter) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return h.ErrorResponse(ctx, event, err)
 	}
 
-	w := core.NewProxyResponseWriter()
-	h.handler.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.NewProxyResponseWriter()
+	defer w.Release()
+	req = w.BindRequest(req, h.TimeoutReserve())
+	if err := h.Serve(h.handler, w, req); err != nil {
+		return h.ErrorResponse(ctx, event, err)
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return h.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return resp, nil
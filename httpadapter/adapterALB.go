@@ -13,10 +13,12 @@ type HandlerAdapterALB struct {
 	handler http.Handler
 }
 
-func NewALB(handler http.Handler) *HandlerAdapterALB {
-	return &HandlerAdapterALB{
+func NewALB(handler http.Handler, opts ...core.Option) *HandlerAdapterALB {
+	h := &HandlerAdapterALB{
 		handler: handler,
 	}
+	h.ApplyOptions(opts...)
+	return h
 }
 
 // Proxy receives an ALB Target Group proxy event, transforms it into an http.Request
@@ -24,7 +26,7 @@ func NewALB(handler http.Handler) *HandlerAdapterALB {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *HandlerAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(context.Background(), event, req, err)
 }
 
 // ProxyWithContext receives context and an ALB proxy event,
@@ -32,20 +34,24 @@ func (h *HandlerAdapterALB) Proxy(event events.ALBTargetGroupRequest) (events.AL
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *HandlerAdapterALB) ProxyWithContext(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
 	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(ctx, event, req, err)
 }
 
-func (h *HandlerAdapterALB) proxyInternal(req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
+func (h *HandlerAdapterALB) proxyInternal(ctx context.Context, event events.ALBTargetGroupRequest, req *http.Request, err error) (events.ALBTargetGroupResponse, error) {
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return h.ErrorResponse(ctx, event, err)
 	}
 
-	w := core.NewProxyResponseWriterALB()
-	h.handler.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.NewProxyResponseWriterALB()
+	defer w.Release()
+	req = w.BindRequest(req, h.TimeoutReserve())
+	if err := h.Serve(h.handler, w, req); err != nil {
+		return h.ErrorResponse(ctx, event, err)
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutALB(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return h.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return resp, nil
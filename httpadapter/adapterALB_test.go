@@ -45,4 +45,58 @@ var _ = Describe("HandlerFuncAdapter tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("Target group header settings", func() {
+		It("Answers in the header format of the event", func() {
+			adapter := httpadapter.NewALB(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				fmt.Fprintf(w, "Go Lambda!!")
+			}))
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.ALBTargetGroupRequest{
+				HTTPMethod:        http.MethodGet,
+				Path:              "/ping",
+				MultiValueHeaders: map[string][]string{"host": {"example.com"}},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusDescription).To(Equal("200 OK"))
+			Expect(resp.Headers).To(BeNil())
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"text/plain"}))
+
+			resp, err = adapter.Proxy(events.ALBTargetGroupRequest{
+				HTTPMethod: http.MethodGet,
+				Path:       "/ping",
+				Headers:    map[string]string{"host": "example.com"},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.MultiValueHeaders).To(BeNil())
+			Expect(resp.Headers["Content-Type"]).To(Equal("text/plain"))
+		})
+
+		It("Answers errors in the header format of the event", func() {
+			adapter := httpadapter.NewALB(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				panic("boom")
+			}))
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.ALBTargetGroupRequest{
+				HTTPMethod:        http.MethodGet,
+				Path:              "/ping",
+				MultiValueHeaders: map[string][]string{"host": {"example.com"}},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(resp.Headers).To(BeNil())
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+
+			resp, err = adapter.ProxyWithContext(context.Background(), events.ALBTargetGroupRequest{
+				HTTPMethod: "GE T",
+				Path:       "/ping",
+				Headers:    map[string]string{"host": "example.com"},
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(resp.MultiValueHeaders).To(BeNil())
+			Expect(resp.Headers["Content-Type"]).To(Equal("application/problem+json"))
+		})
+	})
 })
@@ -2,11 +2,14 @@ package httpadapter_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
 
 	. "github.com/onsi/ginkgo"
@@ -41,4 +44,215 @@ var _ = Describe("HTTPAdapter tests", func() {
 			Expect(resp.StatusCode).To(Equal(200))
 		})
 	})
+
+	Context("Options", func() {
+		It("Applies the options to the converted request and the response", func() {
+			var reqURL string
+			adapter := httpadapter.New(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				reqURL = req.URL.String()
+				w.Header().Set("Content-Type", "image/png")
+				fmt.Fprintf(w, "not really a png")
+			}), core.WithCustomHost("http://example.com"), core.WithBasePath("/api"), core.WithBinaryContentTypes("image/*"))
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/api/ping",
+				HTTPMethod: "GET",
+			})
+
+			Expect(err).To(BeNil())
+			Expect(reqURL).To(Equal("http://example.com/ping"))
+			Expect(resp.IsBase64Encoded).To(BeTrue())
+		})
+
+		It("Answers bodies larger than the maximum size with a 413 response", func() {
+			called := false
+			adapter := httpadapter.New(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				called = true
+			}), core.WithMaxBodySize(4), core.WithBodyTooLargeResponse("text/plain", "too large"))
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/upload",
+				HTTPMethod: "POST",
+				Body:       "hello",
+			})
+
+			Expect(err).To(BeNil())
+			Expect(called).To(BeFalse())
+			Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"text/plain"}))
+			Expect(resp.Body).To(Equal("too large"))
+		})
+
+		It("Restores the stage in redirects", func() {
+			var reqPath string
+			adapter := httpadapter.New(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				reqPath = req.URL.Path
+				http.Redirect(w, req, "/login", http.StatusFound)
+			}), core.WithAutoBasePath())
+
+			resp, err := adapter.ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+				Resource:   "/ping",
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Stage: "prod",
+					Path:  "/prod/ping",
+				},
+			})
+
+			Expect(err).To(BeNil())
+			Expect(reqPath).To(Equal("/ping"))
+			Expect(resp.StatusCode).To(Equal(http.StatusFound))
+			Expect(resp.MultiValueHeaders["Location"]).To(Equal([]string{"/prod/login"}))
+		})
+	})
+
+	Context("Invalid events", func() {
+		It("Answers malformed events with a bad request", func() {
+			called := false
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				called = true
+			})
+
+			resp, err := httpadapter.New(handler).ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:            "/ping",
+				HTTPMethod:      "POST",
+				Body:            "not base64!",
+				IsBase64Encoded: true,
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+			respV2, err := httpadapter.NewV2(handler).ProxyWithContext(context.Background(), events.APIGatewayV2HTTPRequest{
+				RawPath: "/ping",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GE T"},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(respV2.StatusCode).To(Equal(http.StatusBadRequest))
+
+			respALB, err := httpadapter.NewALB(handler).ProxyWithContext(context.Background(), events.ALBTargetGroupRequest{
+				Path:       "/ping%zz",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(respALB.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(called).To(BeFalse())
+		})
+	})
+
+	Context("Error responses", func() {
+		It("Answers invalid handler responses with problem details", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(0)
+			})
+
+			resp, err := httpadapter.New(handler).ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(resp.MultiValueHeaders["Content-Type"]).To(Equal([]string{"application/problem+json"}))
+
+			var mapped error
+			mapper := core.ErrorMapperFunc(func(ctx context.Context, w http.ResponseWriter, err error) {
+				mapped = err
+				w.WriteHeader(core.ErrorStatusCode(err))
+			})
+			respALB, err := httpadapter.NewALB(handler, core.WithErrorMapper(mapper)).ProxyWithContext(context.Background(), events.ALBTargetGroupRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(respALB.StatusCode).To(Equal(http.StatusBadGateway))
+			var responseErr *core.ResponseError
+			Expect(errors.As(mapped, &responseErr)).To(BeTrue())
+		})
+	})
+
+	Context("Panics", func() {
+		It("Answers handler panics with a 500 in every event format", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				panic("boom")
+			})
+
+			resp, err := httpadapter.New(handler).ProxyWithContext(context.Background(), events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+
+			respV2, err := httpadapter.NewV2(handler).ProxyWithContext(context.Background(), events.APIGatewayV2HTTPRequest{
+				RawPath: "/ping",
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET"},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(respV2.StatusCode).To(Equal(http.StatusInternalServerError))
+
+			respALB, err := httpadapter.NewALB(handler).ProxyWithContext(context.Background(), events.ALBTargetGroupRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			})
+			Expect(err).To(BeNil())
+			Expect(respALB.StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(respALB.StatusDescription).To(Equal("500 Internal Server Error"))
+		})
+	})
+
+	Context("Request context", func() {
+		It("Derives the request deadline from the invocation deadline", func() {
+			deadline := time.Now().Add(10 * time.Second)
+			var reqDeadline time.Time
+			var reqCtx context.Context
+
+			adapter := httpadapter.New(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				reqDeadline, _ = req.Context().Deadline()
+				reqCtx = req.Context()
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			adapter.SetTimeoutReserve(time.Second)
+
+			ctx, cancel := context.WithDeadline(context.Background(), deadline)
+			defer cancel()
+
+			resp, err := adapter.ProxyWithContext(ctx, events.APIGatewayProxyRequest{
+				Path:       "/ping",
+				HTTPMethod: "GET",
+			})
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+			Expect(reqDeadline).To(Equal(deadline.Add(-time.Second)))
+			Expect(reqCtx.Err()).To(Equal(context.Canceled))
+		})
+	})
+
+	Context("Invocation timeout", func() {
+		It("Returns a gateway timeout before the invocation deadline", func() {
+			release := make(chan struct{})
+			defer close(release)
+
+			adapter := httpadapter.New(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				<-release
+			}))
+			adapter.SetTimeoutReserve(50 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			resp, err := adapter.ProxyWithContext(ctx, events.APIGatewayProxyRequest{
+				Path:       "/slow",
+				HTTPMethod: "GET",
+			})
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusGatewayTimeout))
+			Expect(ctx.Err()).To(BeNil())
+		})
+	})
 })
@@ -13,10 +13,12 @@ type HandlerAdapterV2 struct {
 	handler http.Handler
 }
 
-func NewV2(handler http.Handler) *HandlerAdapterV2 {
-	return &HandlerAdapterV2{
+func NewV2(handler http.Handler, opts ...core.Option) *HandlerAdapterV2 {
+	h := &HandlerAdapterV2{
 		handler: handler,
 	}
+	h.ApplyOptions(opts...)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -24,7 +26,7 @@ func NewV2(handler http.Handler) *HandlerAdapterV2 {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *HandlerAdapterV2) Proxy(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(context.Background(), event, req, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -32,20 +34,24 @@ func (h *HandlerAdapterV2) Proxy(event events.APIGatewayV2HTTPRequest) (events.A
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *HandlerAdapterV2) ProxyWithContext(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(ctx, event, req, err)
 }
 
-func (h *HandlerAdapterV2) proxyInternal(req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
+func (h *HandlerAdapterV2) proxyInternal(ctx context.Context, event events.APIGatewayV2HTTPRequest, req *http.Request, err error) (events.APIGatewayV2HTTPResponse, error) {
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return h.ErrorResponse(ctx, event, err)
 	}
 
-	w := core.NewProxyResponseWriterV2()
-	h.handler.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.NewProxyResponseWriterV2()
+	defer w.Release()
+	req = w.BindRequest(req, h.TimeoutReserve())
+	if err := h.Serve(h.handler, w, req); err != nil {
+		return h.ErrorResponse(ctx, event, err)
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeoutV2(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return h.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return resp, nil
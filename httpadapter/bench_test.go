@@ -0,0 +1,18 @@
+package httpadapter_test
+
+import (
+	"testing"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+)
+
+// BenchmarkProxy measures the time and allocations of proxying an event to
+// the net/http handler for every event type, without the routing of a framework.
+func BenchmarkProxy(b *testing.B) {
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway:   httpadapter.New(proxybench.Handler).ProxyWithContext,
+		APIGatewayV2: httpadapter.NewV2(proxybench.Handler).ProxyWithContext,
+		ALB:          httpadapter.NewALB(proxybench.Handler).ProxyWithContext,
+	})
+}
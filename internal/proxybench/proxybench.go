@@ -0,0 +1,119 @@
+// Package proxybench benchmarks the adapters with the same events, so that the
+// time and allocations of proxying an event can be compared across frameworks.
+package proxybench
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// Body is the JSON body the benchmarked handlers respond with.
+var Body = []byte(`{"id":"42","name":"order","items":[1,2,3]}`)
+
+// Handler responds to every request with Body.
+var Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(Body)
+})
+
+// Adapters holds the ProxyWithContext methods of an adapter for the event
+// types it supports. The event types whose method is nil are not benchmarked.
+type Adapters struct {
+	APIGateway   func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+	APIGatewayV2 func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+	ALB          func(context.Context, events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error)
+}
+
+// benchmark proxies the event of an event type and returns the status code of
+// the response.
+type benchmark struct {
+	name  string
+	proxy func(ctx context.Context) (int, error)
+}
+
+// Run measures the time and allocations of proxying a GET /orders/42 event
+// of every event type supported by a, in a sub-benchmark named after the
+// event type. As in a Lambda invocation, the context of the events carries a
+// request ID and a deadline, so that the handler is served under a deadline.
+// The handler must respond with 200 OK.
+func Run(b *testing.B, a Adapters) {
+	var benchmarks []benchmark
+	if a.APIGateway != nil {
+		event := events.APIGatewayProxyRequest{
+			HTTPMethod:                      "GET",
+			Path:                            "/orders/42",
+			Resource:                        "/orders/{id}",
+			MultiValueHeaders:               map[string][]string{"Accept": {"application/json"}, "User-Agent": {"bench"}},
+			MultiValueQueryStringParameters: map[string][]string{"page": {"1"}, "size": {"20"}},
+			RequestContext:                  events.APIGatewayProxyRequestContext{RequestID: "x", Stage: "prod", DomainName: "api.example.com"},
+		}
+		benchmarks = append(benchmarks, benchmark{"APIGatewayProxyRequest", func(ctx context.Context) (int, error) {
+			resp, err := a.APIGateway(ctx, event)
+			return resp.StatusCode, err
+		}})
+	}
+	if a.APIGatewayV2 != nil {
+		event := events.APIGatewayV2HTTPRequest{
+			RouteKey:       "GET /orders/{id}",
+			RawPath:        "/orders/42",
+			RawQueryString: "page=1&size=20",
+			Headers:        map[string]string{"accept": "application/json", "user-agent": "bench"},
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				RequestID:  "x",
+				DomainName: "api.example.com",
+				HTTP:       events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/orders/42"},
+			},
+		}
+		benchmarks = append(benchmarks, benchmark{"APIGatewayV2HTTPRequest", func(ctx context.Context) (int, error) {
+			resp, err := a.APIGatewayV2(ctx, event)
+			return resp.StatusCode, err
+		}})
+	}
+	if a.ALB != nil {
+		event := events.ALBTargetGroupRequest{
+			HTTPMethod:                      "GET",
+			Path:                            "/orders/42",
+			MultiValueHeaders:               map[string][]string{"accept": {"application/json"}, "host": {"api.example.com"}},
+			MultiValueQueryStringParameters: map[string][]string{"page": {"1"}, "size": {"20"}},
+			RequestContext: events.ALBTargetGroupRequestContext{
+				ELB: events.ELBContext{TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/bench/0123456789abcdef"},
+			},
+		}
+		benchmarks = append(benchmarks, benchmark{"ALBTargetGroupRequest", func(ctx context.Context) (int, error) {
+			resp, err := a.ALB(ctx, event)
+			return resp.StatusCode, err
+		}})
+	}
+
+	for _, bm := range benchmarks {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			ctx, cancel := invocationContext()
+			defer cancel()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				status, err := bm.proxy(ctx)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if status != http.StatusOK {
+					b.Fatalf("unexpected status %d", status)
+				}
+			}
+		})
+	}
+}
+
+// invocationContext returns the context of a Lambda invocation with the
+// maximum timeout of a function, 15 minutes.
+func invocationContext() (context.Context, context.CancelFunc) {
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-1"})
+	return context.WithTimeout(ctx, 15*time.Minute)
+}
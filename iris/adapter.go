@@ -5,6 +5,7 @@ package irisadapter
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -23,9 +24,12 @@ type IrisLambda struct {
 
 // New creates a new instance of the IrisLambda object.
 // Receives an initialized *iris.Application object - normally created with iris.Default().
+// The given options configure how proxy events are converted, see core.Option.
 // It returns the initialized instance of the IrisLambda object.
-func New(app *iris.Application) *IrisLambda {
-	return &IrisLambda{application: app}
+func New(app *iris.Application, opts ...core.Option) *IrisLambda {
+	i := &IrisLambda{application: app}
+	i.ApplyOptions(opts...)
+	return i
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -33,7 +37,7 @@ func New(app *iris.Application) *IrisLambda {
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (i *IrisLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	irisRequest, err := i.ProxyEventToHTTPRequest(req)
-	return i.proxyInternal(irisRequest, err)
+	return i.proxyInternal(context.Background(), req, irisRequest, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -41,24 +45,28 @@ func (i *IrisLambda) Proxy(req events.APIGatewayProxyRequest) (events.APIGateway
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (i *IrisLambda) ProxyWithContext(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	irisRequest, err := i.EventToRequestWithContext(ctx, req)
-	return i.proxyInternal(irisRequest, err)
+	return i.proxyInternal(ctx, req, irisRequest, err)
 }
 
-func (i *IrisLambda) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+func (i *IrisLambda) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return i.ErrorResponse(ctx, event, err)
 	}
 
 	if err := i.application.Build(); err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Iris set up failed: %v", err)
+		return i.ErrorResponse(ctx, event, fmt.Errorf("Iris set up failed: %w", err))
 	}
 
-	respWriter := core.NewProxyResponseWriter()
-	i.application.ServeHTTP(http.ResponseWriter(respWriter), req)
+	respWriter := i.NewProxyResponseWriter()
+	defer respWriter.Release()
+	req = respWriter.BindRequest(req, i.TimeoutReserve())
+	if err := i.Serve(i.application, respWriter, req); err != nil {
+		return i.ErrorResponse(ctx, event, err)
+	}
 
 	proxyResponse, err := respWriter.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return i.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return proxyResponse, nil
@@ -0,0 +1,26 @@
+package irisadapter_test
+
+import (
+	"testing"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+	irisadapter "github.com/awslabs/aws-lambda-go-api-proxy/iris"
+	"github.com/kataras/iris/v12"
+)
+
+func newBenchmarkApp() *iris.Application {
+	app := iris.New()
+	app.Get("/orders/{id}", func(ctx iris.Context) {
+		ctx.ContentType("application/json")
+		ctx.Write(proxybench.Body)
+	})
+	return app
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway: irisadapter.New(newBenchmarkApp()).ProxyWithContext,
+	})
+}
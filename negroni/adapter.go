@@ -14,10 +14,12 @@ type NegroniAdapter struct {
 	n *negroni.Negroni
 }
 
-func New(n *negroni.Negroni) *NegroniAdapter {
-	return &NegroniAdapter{
+func New(n *negroni.Negroni, opts ...core.Option) *NegroniAdapter {
+	h := &NegroniAdapter{
 		n: n,
 	}
+	h.ApplyOptions(opts...)
+	return h
 }
 
 // Proxy receives an API Gateway proxy event, transforms it into an http.Request
@@ -25,7 +27,7 @@ func New(n *negroni.Negroni) *NegroniAdapter {
 // It returns a proxy response object generated from the http.Handler.
 func (h *NegroniAdapter) Proxy(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	req, err := h.ProxyEventToHTTPRequest(event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(context.Background(), event, req, err)
 }
 
 // ProxyWithContext receives context and an API Gateway proxy event,
@@ -33,20 +35,24 @@ func (h *NegroniAdapter) Proxy(event events.APIGatewayProxyRequest) (events.APIG
 // It returns a proxy response object generated from the http.ResponseWriter.
 func (h *NegroniAdapter) ProxyWithContext(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	req, err := h.EventToRequestWithContext(ctx, event)
-	return h.proxyInternal(req, err)
+	return h.proxyInternal(ctx, event, req, err)
 }
 
-func (h *NegroniAdapter) proxyInternal(req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
+func (h *NegroniAdapter) proxyInternal(ctx context.Context, event events.APIGatewayProxyRequest, req *http.Request, err error) (events.APIGatewayProxyResponse, error) {
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Could not convert proxy event to request: %v", err)
+		return h.ErrorResponse(ctx, event, err)
 	}
 
-	w := core.NewProxyResponseWriter()
-	h.n.ServeHTTP(http.ResponseWriter(w), req)
+	w := h.NewProxyResponseWriter()
+	defer w.Release()
+	req = w.BindRequest(req, h.TimeoutReserve())
+	if err := h.Serve(h.n, w, req); err != nil {
+		return h.ErrorResponse(ctx, event, err)
+	}
 
 	resp, err := w.GetProxyResponse()
 	if err != nil {
-		return core.GatewayTimeout(), core.NewLoggedError("Error while generating proxy response: %v", err)
+		return h.ErrorResponse(ctx, event, &core.ResponseError{Err: err})
 	}
 
 	return resp, nil
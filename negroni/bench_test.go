@@ -0,0 +1,23 @@
+package negroniadapter_test
+
+import (
+	"testing"
+
+	"github.com/awslabs/aws-lambda-go-api-proxy/internal/proxybench"
+	negroniadapter "github.com/awslabs/aws-lambda-go-api-proxy/negroni"
+	"github.com/urfave/negroni"
+)
+
+func newBenchmarkNegroni() *negroni.Negroni {
+	n := negroni.New()
+	n.UseHandler(proxybench.Handler)
+	return n
+}
+
+// BenchmarkProxy measures the time and allocations of proxying an event
+// through the framework for every event type the adapter supports.
+func BenchmarkProxy(b *testing.B) {
+	proxybench.Run(b, proxybench.Adapters{
+		APIGateway: negroniadapter.New(newBenchmarkNegroni()).ProxyWithContext,
+	})
+}